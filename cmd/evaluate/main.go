@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/danyatalent/movie-recommend/internal/config"
+	directordb "github.com/danyatalent/movie-recommend/internal/director/db"
+	eventsdb "github.com/danyatalent/movie-recommend/internal/events/db"
+	moviedb "github.com/danyatalent/movie-recommend/internal/movie/db"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	ratingdb "github.com/danyatalent/movie-recommend/internal/rating/db"
+	"github.com/danyatalent/movie-recommend/internal/recommend"
+	watchlistdb "github.com/danyatalent/movie-recommend/internal/watchlist/db"
+	"github.com/danyatalent/movie-recommend/pkg/client/postgresql"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/joho/godotenv"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	configPath = flag.String("config-path", "configs/config.yaml", "path to config file, used with -source db")
+	source     = flag.String("source", "db", "where to read ratings from: db or file")
+	file       = flag.String("file", "", "csv file with ratings, used with -source file")
+	dump       = flag.String("dump", "", "write ratings read from source into csv file and exit")
+	split      = flag.String("split", "random", "how to split ratings into train and test: random or time")
+	testShare  = flag.Float64("test", 0.2, "fraction of ratings in test set")
+	k          = flag.Int("k", 10, "length of recommendation list")
+	threshold  = flag.Float64("threshold", 7, "minimal score of relevant movie")
+	seed       = flag.Int64("seed", 42, "random seed")
+	format     = flag.String("format", "table", "output format: table or json")
+	strategies = flag.String("strategies", "", "comma separated strategies to evaluate, all registered by default, hybrid only with -source db")
+	neighbours = flag.Int("neighbours", 50, "number of neighbours kept by item-cf")
+	factors    = flag.Int("factors", 20, "number of latent factors of als")
+	iterations = flag.Int("iterations", 15, "number of als iterations")
+	lambda     = flag.Float64("lambda", 0.1, "als regularization")
+)
+
+func main() {
+	flag.Parse()
+	ctx := context.Background()
+	// logs are kept quiet so that stdout contains only results
+	logger := logging.InitLogger(logging.ErrorLevel)
+
+	in, err := readInput(ctx, logger)
+	if err != nil {
+		log.Fatalf("can't read ratings: %v", err)
+	}
+	ratings := in.ratings
+	if *dump != "" {
+		if err = dumpRatings(*dump, ratings); err != nil {
+			log.Fatalf("can't dump ratings: %v", err)
+		}
+		return
+	}
+
+	var train, test []rating.Rating
+	switch *split {
+	case "random":
+		train, test = recommend.SplitRandom(ratings, *testShare, *seed)
+	case "time":
+		train, test = recommend.SplitByTime(ratings, *testShare)
+	default:
+		log.Fatalf("unknown split: %s", *split)
+	}
+	trainSet, testSet := recommend.NewDataset(train), recommend.NewDataset(test)
+
+	registry := recommend.DefaultRegistry(logger, *neighbours, recommend.ALSParams{
+		Factors:    *factors,
+		Iterations: *iterations,
+		Lambda:     *lambda,
+		Seed:       *seed,
+	})
+	if in.catalog != nil {
+		// taste profiles are built from train split only, test ratings must stay unseen
+		profiles := recommend.NewProfiles(in.catalog, trainFeedback(train), nil, logger,
+			in.cfg.Taste.HalfLife, in.cfg.Taste.TopDirectors)
+		if err = profiles.Refresh(ctx); err != nil {
+			log.Fatalf("can't build taste profiles: %v", err)
+		}
+		registry.RegisterHybrid(in.catalog, profiles, *neighbours,
+			recommend.HybridWeights{
+				Collaborative: in.cfg.Hybrid.NewUserCollaborative,
+				Content:       in.cfg.Hybrid.NewUserContent,
+				Popularity:    in.cfg.Hybrid.NewUserPopularity,
+			},
+			recommend.HybridWeights{
+				Collaborative: in.cfg.Hybrid.HeavyUserCollaborative,
+				Content:       in.cfg.Hybrid.HeavyUserContent,
+				Popularity:    in.cfg.Hybrid.HeavyUserPopularity,
+			},
+			in.cfg.Hybrid.HeavyRatings,
+		)
+	}
+	names := registry.Names()
+	if *strategies != "" {
+		names = strings.Split(*strategies, ",")
+	}
+
+	results := make([]recommend.Metrics, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		strategy, ok := registry.New(name)
+		if !ok {
+			if name == "hybrid" {
+				log.Fatalf("hybrid needs movie catalog, it is evaluated only with -source db")
+			}
+			log.Fatalf("unknown strategy: %s, registered: %s", name, strings.Join(registry.Names(), ", "))
+		}
+		if err = strategy.Fit(trainSet); err != nil {
+			log.Fatalf("can't fit %s: %v", name, err)
+		}
+		m, err := recommend.Evaluate(ctx, strategy, trainSet, testSet, *k, *threshold)
+		if err != nil {
+			log.Fatalf("can't evaluate %s: %v", name, err)
+		}
+		results = append(results, m)
+	}
+
+	if err = printResults(results); err != nil {
+		log.Fatalf("can't print results: %v", err)
+	}
+}
+
+// input is what strategies are evaluated on, catalog and config are read only with -source db
+type input struct {
+	ratings []rating.Rating
+	catalog *recommend.Catalog
+	cfg     *config.Config
+}
+
+func readInput(ctx context.Context, logger *slog.Logger) (input, error) {
+	switch *source {
+	case "file":
+		f, err := os.Open(*file)
+		if err != nil {
+			return input{}, err
+		}
+		defer f.Close()
+		ratings, err := rating.ReadCSV(f)
+		return input{ratings: ratings}, err
+	case "db":
+		if err := godotenv.Load(); err != nil {
+			return input{}, err
+		}
+		cfg, err := config.ReadConfig(*configPath)
+		if err != nil {
+			return input{}, err
+		}
+		pool, err := postgresql.NewClient(logger, ctx, 3, cfg.Storage)
+		if err != nil {
+			return input{}, err
+		}
+		defer pool.Close()
+		// strategies are evaluated on the same merged feedback server and cmd/train fit them on
+		feedback := recommend.NewFeedback(ratingdb.NewRepository(pool, logger),
+			eventsdb.NewRepository(pool, logger), watchlistdb.NewRepository(pool, logger))
+		ratings, err := feedback.GetAllRatings(ctx)
+		if err != nil {
+			return input{}, err
+		}
+		catalog := recommend.NewCatalog(moviedb.NewRepository(pool, logger), directordb.NewRepository(pool, logger))
+		if err = catalog.Refresh(ctx); err != nil {
+			return input{}, err
+		}
+		return input{ratings: ratings, catalog: catalog, cfg: cfg}, nil
+	default:
+		return input{}, fmt.Errorf("unknown source: %s", *source)
+	}
+}
+
+// trainFeedback gives taste profiles train ratings as all feedback there is
+type trainFeedback []rating.Rating
+
+func (f trainFeedback) GetAllRatings(context.Context) ([]rating.Rating, error) {
+	return f, nil
+}
+
+func (f trainFeedback) GetUserRatings(_ context.Context, userID string) ([]rating.Rating, error) {
+	ratings := make([]rating.Rating, 0)
+	for _, rt := range f {
+		if rt.UserID == userID {
+			ratings = append(ratings, rt)
+		}
+	}
+	return ratings, nil
+}
+
+func dumpRatings(path string, ratings []rating.Rating) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return rating.WriteCSV(f, ratings)
+}
+
+func printResults(results []recommend.Metrics) error {
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STRATEGY\tUSERS\tPRECISION@K\tRECALL@K\tMAP\tNDCG\tCOVERAGE\tNOVELTY")
+	for _, m := range results {
+		fmt.Fprintf(w, "%s\t%d\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\n",
+			m.Strategy, m.Users, m.Precision, m.Recall, m.MAP, m.NDCG, m.Coverage, m.Novelty)
+	}
+	return w.Flush()
+}
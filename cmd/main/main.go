@@ -6,10 +6,23 @@ import (
 	_ "github.com/danyatalent/movie-recommend/docs"
 	"github.com/danyatalent/movie-recommend/internal/config"
 	director "github.com/danyatalent/movie-recommend/internal/director/db"
+	"github.com/danyatalent/movie-recommend/internal/events"
+	eventsdb "github.com/danyatalent/movie-recommend/internal/events/db"
+	exclusion "github.com/danyatalent/movie-recommend/internal/exclusion/db"
+	"github.com/danyatalent/movie-recommend/internal/experiment"
 	genre "github.com/danyatalent/movie-recommend/internal/genre/db"
 	"github.com/danyatalent/movie-recommend/internal/handlers"
 	movie "github.com/danyatalent/movie-recommend/internal/movie/db"
+	onboarding "github.com/danyatalent/movie-recommend/internal/onboarding/db"
+	rating "github.com/danyatalent/movie-recommend/internal/rating/db"
+	"github.com/danyatalent/movie-recommend/internal/recommend"
+	model "github.com/danyatalent/movie-recommend/internal/recommend/db"
+	session "github.com/danyatalent/movie-recommend/internal/session/db"
+	slate "github.com/danyatalent/movie-recommend/internal/slate/db"
+	"github.com/danyatalent/movie-recommend/internal/trending"
+	trendingdb "github.com/danyatalent/movie-recommend/internal/trending/db"
 	user "github.com/danyatalent/movie-recommend/internal/user/db"
+	watchlist "github.com/danyatalent/movie-recommend/internal/watchlist/db"
 	"github.com/danyatalent/movie-recommend/pkg/client/postgresql"
 	logging "github.com/danyatalent/movie-recommend/pkg/logger"
 	"github.com/go-chi/chi/v5"
@@ -20,6 +33,7 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
 )
 
 // @title Movie JSON API
@@ -54,6 +68,133 @@ func main() {
 	userRepository := user.NewRepository(postgresPool, logger)
 	directorRepository := director.NewRepository(postgresPool, logger)
 	movieRepository := movie.NewRepository(postgresPool, logger)
+	ratingRepository := rating.NewRepository(postgresPool, logger)
+	onboardingRepository := onboarding.NewRepository(postgresPool, logger)
+	eventsRepository := eventsdb.NewRepository(postgresPool, logger)
+	watchlistRepository := watchlist.NewRepository(postgresPool, logger)
+	exclusionRepository := exclusion.NewRepository(postgresPool, logger)
+	slateRepository := slate.NewRepository(postgresPool, logger)
+	sessionRepository := session.NewRepository(postgresPool, logger)
+	modelRepository := model.NewRepository(postgresPool, logger)
+
+	// Events are written to postgres in batches
+	eventsBatcher := events.NewBatcher(eventsRepository, logger, cfg.BatchSize, cfg.QueueSize, cfg.FlushInterval)
+	go eventsBatcher.Run(ctx)
+
+	// Experiments route users between strategies, every strategy a variant refers to is fitted
+	experiments, err := experiment.New(experimentsFromConfig(cfg.Experiments))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Init recommender, it is refreshed in background
+	catalog := recommend.NewCatalog(movieRepository, directorRepository)
+	itemCF := recommend.NewItemCF(cfg.Neighbours)
+	popular := recommend.NewPopular()
+	feedback := recommend.NewFeedback(ratingRepository, eventsRepository, watchlistRepository)
+	// Taste profiles are built once and then updated for users with new feedback
+	profiles := recommend.NewProfiles(catalog, feedback, modelRepository, logger, cfg.Taste.HalfLife, cfg.Taste.TopDirectors)
+	hybrid := recommend.NewHybrid(catalog, profiles, itemCF, popular,
+		recommend.HybridWeights{
+			Collaborative: cfg.Hybrid.NewUserCollaborative,
+			Content:       cfg.Hybrid.NewUserContent,
+			Popularity:    cfg.Hybrid.NewUserPopularity,
+		},
+		recommend.HybridWeights{
+			Collaborative: cfg.Hybrid.HeavyUserCollaborative,
+			Content:       cfg.Hybrid.HeavyUserContent,
+			Popularity:    cfg.Hybrid.HeavyUserPopularity,
+		},
+		cfg.Hybrid.HeavyRatings,
+	)
+	var strategy recommend.Strategy = itemCF
+	if cfg.Strategy == "hybrid" {
+		strategy = hybrid
+	}
+	variants := []recommend.Strategy{itemCF, popular, hybrid}
+	if cfg.Strategy == "als" || slices.Contains(experiments.Strategies(), "als") {
+		// factor model is trained by cmd/train, server only loads and hot-swaps its versions
+		mf := recommend.NewMF(logger)
+		if err = mf.Load(ctx, modelRepository); err != nil {
+			logger.Error("cannot load factor model", logging.Err(err))
+		}
+		go mf.Watch(ctx, modelRepository, cfg.ModelPollInterval)
+		variants = append(variants, mf)
+		if cfg.Strategy == "als" {
+			strategy = mf
+		}
+	}
+	router, err := recommend.NewRouter(strategy, experiments, variants...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	coldStart := recommend.NewColdStart(catalog, onboardingRepository)
+	explainer := recommend.NewExplainer(catalog, onboardingRepository, directorRepository)
+	diversity := recommend.Diversity{
+		Lambda:         cfg.Diversity.Lambda,
+		MaxPerDirector: cfg.Diversity.MaxPerDirector,
+		MaxPerGenre:    cfg.Diversity.MaxPerGenre,
+	}
+	cache := recommend.NewCache(cfg.Cache.Size, cfg.Cache.Users)
+	// Bandit learns click rates of movies from served slates, new movies get optimistic prior
+	bandit := recommend.NewBandit(catalog, slateRepository, logger, recommend.Exploration{
+		Slots:            cfg.Exploration.Slots,
+		PriorClicks:      cfg.Exploration.PriorClicks,
+		PriorImpressions: cfg.Exploration.PriorImpressions,
+		NewMovieBoost:    cfg.Exploration.NewMovieBoost,
+		NewMovieWindow:   cfg.Exploration.NewMovieWindow,
+		PoolSize:         cfg.Exploration.PoolSize,
+	})
+	// Exposure of directors and countries is loaded from served slates and grows with every served list
+	exposure := recommend.NewExposure(catalog, slateRepository, logger, recommend.Fairness{
+		MinNonOscarShare: cfg.Fairness.MinNonOscarShare,
+		MaxDirectorShare: cfg.Fairness.MaxDirectorShare,
+		MaxCountryShare:  cfg.Fairness.MaxCountryShare,
+		Strength:         cfg.Fairness.Strength,
+		Window:           cfg.Fairness.Window,
+	})
+	recommender := recommend.NewService(logger, catalog, feedback, router, coldStart, exclusionRepository,
+		explainer, eventsBatcher, slateRepository, cache, bandit, exposure, diversity, cfg.MinRatings)
+	if err = recommender.Refresh(ctx); err != nil {
+		logger.Error("cannot fit recommender", logging.Err(err))
+	}
+	go recommender.Run(ctx, cfg.Recommend.RefreshInterval)
+	// exploration pool is picked from catalog loaded by recommender refresh
+	if err = bandit.Refresh(ctx); err != nil {
+		logger.Error("cannot load bandit stats", logging.Err(err))
+	}
+	go bandit.Run(ctx, cfg.Exploration.RefreshInterval)
+	// exposure is mapped to directors through catalog loaded by recommender refresh
+	if err = exposure.Refresh(ctx); err != nil {
+		logger.Error("cannot load exposure", logging.Err(err))
+	}
+	go exposure.Run(ctx, cfg.Fairness.RefreshInterval)
+	// catalog is loaded by recommender refresh, profiles need genres and directors of movies
+	if err = profiles.Refresh(ctx); err != nil {
+		logger.Error("cannot build taste profiles", logging.Err(err))
+	}
+	go profiles.Run(ctx, cfg.Taste.RefreshInterval, cfg.Taste.RebuildInterval)
+	// Lists of active users are precomputed, requests of other users with ratings compute and cache them on a miss
+	precomputer := recommend.NewPrecomputer(recommender, cache, modelRepository, logger,
+		cfg.Cache.MaxAge, cfg.Cache.MaxIdle, cfg.Cache.ActiveWindow)
+	go precomputer.Run(ctx, cfg.Cache.RefreshInterval)
+	content := recommend.NewContent(catalog, exclusionRepository)
+	// Anonymous sessions are served by transitions between movies mined in background
+	sessions := recommend.NewSessions(catalog, sessionRepository, logger, cfg.Sessions.Window, cfg.Sessions.Gap)
+	if err = sessions.Refresh(ctx); err != nil {
+		logger.Error("cannot mine session transitions", logging.Err(err))
+	}
+	go sessions.Run(ctx, cfg.Sessions.RefreshInterval)
+
+	// Trending and top lists are refreshed in background, requests only read them
+	ranker := trending.NewRanker(trendingdb.NewRepository(postgresPool, logger), catalog, exclusionRepository, logger, cfg.MinVotes)
+	if err = ranker.Refresh(ctx); err != nil {
+		logger.Error("cannot compute rankings", logging.Err(err))
+	}
+	go ranker.Run(ctx, cfg.Trending.RefreshInterval)
+	feeds := recommend.NewFeeds(recommender, catalog, exclusionRepository, content, ranker, profiles,
+		eventsRepository, ratingRepository)
+	compatibilities := recommend.NewCompatibilities(catalog, userRepository, ratingRepository, profiles)
 
 	// Init router and middlewares
 	r := chi.NewRouter()
@@ -76,6 +217,21 @@ func main() {
 		r.Get("/{id}", handlers.NewGetUserByID(ctx, logger, userRepository))
 		r.Post("/", handlers.NewCreateUser(ctx, logger, userRepository))
 		r.Put("/{id}", handlers.NewUpdateUser(ctx, logger, userRepository))
+		r.Get("/{id}/ratings", handlers.NewGetUserRatings(ctx, logger, ratingRepository))
+		r.Get("/{id}/recommendations", handlers.NewGetRecommendations(ctx, logger, recommender))
+		r.Get("/{id}/recommendations/{movieID}/why", handlers.NewGetRecommendationReason(ctx, logger, recommender))
+		r.Get("/{id}/taste-profile", handlers.NewGetTasteProfile(ctx, logger, profiles))
+		r.Get("/{id}/feed", handlers.NewGetFeed(ctx, logger, feeds))
+		r.Get("/{id}/feed/{rowID}", handlers.NewGetFeedRow(ctx, logger, feeds))
+		r.Get("/{id}/compatibility/{otherID}", handlers.NewGetCompatibility(ctx, logger, compatibilities))
+		r.Get("/{id}/onboarding", handlers.NewGetOnboarding(ctx, logger, coldStart))
+		r.Post("/{id}/onboarding", handlers.NewSaveOnboarding(ctx, logger, onboardingRepository))
+		r.Get("/{id}/watchlist", handlers.NewGetWatchlist(ctx, logger, watchlistRepository))
+		r.Post("/{id}/watchlist/{movieID}", handlers.NewAddToWatchlist(ctx, logger, watchlistRepository))
+		r.Delete("/{id}/watchlist/{movieID}", handlers.NewRemoveFromWatchlist(ctx, logger, watchlistRepository))
+		r.Get("/{id}/not-interested", handlers.NewGetExclusions(ctx, logger, exclusionRepository))
+		r.Post("/{id}/not-interested", handlers.NewAddExclusion(ctx, logger, exclusionRepository))
+		r.Delete("/{id}/not-interested/{kind}/{targetID}", handlers.NewRemoveExclusion(ctx, logger, exclusionRepository))
 	})
 
 	// director routing
@@ -87,8 +243,33 @@ func main() {
 	// movie routing
 	r.Route("/movies", func(r chi.Router) {
 		r.Get("/{id}", handlers.NewGetMovie(ctx, logger, movieRepository))
-		r.Post("/", handlers.NewCreateMovie(ctx, logger, movieRepository))
+		r.Post("/", handlers.NewCreateMovie(ctx, logger, movieRepository, catalog))
+		r.Put("/{id}", handlers.NewUpdateMovie(ctx, logger, movieRepository, catalog))
+		r.Get("/trending", handlers.NewGetTrending(ctx, logger, ranker))
+		r.Get("/top", handlers.NewGetTop(ctx, logger, ranker))
+		r.Get("/{id}/similar", handlers.NewGetSimilarMovies(ctx, logger, content))
+		r.Put("/{id}/ratings", handlers.NewRateMovie(ctx, logger, ratingRepository))
+		r.Delete("/{id}/ratings", handlers.NewDeleteRating(ctx, logger, ratingRepository))
+	})
+	r.Post("/events", handlers.NewPostEvents(ctx, logger, eventsBatcher))
+
+	// anonymous sessions routing
+	r.Route("/sessions", func(r chi.Router) {
+		r.Post("/{token}/views", handlers.NewPostSessionView(ctx, logger, sessionRepository))
+		r.Get("/{token}/recommendations", handlers.NewGetSessionRecommendations(ctx, logger, sessions))
 	})
+
+	r.Post("/recommendations/group", handlers.NewPostGroupRecommendations(ctx, logger, recommender))
+	r.Post("/recommendations/{id}/feedback", handlers.NewPostRecommendationFeedback(ctx, logger, slateRepository))
+
+	// admin routing
+	r.Route("/admin", func(r chi.Router) {
+		r.Get("/experiments", handlers.NewGetExperiments(ctx, logger, experiments))
+		r.Get("/recommendations/report", handlers.NewGetCTRReport(ctx, logger, slateRepository))
+		r.Get("/bandit", handlers.NewGetBanditMetrics(ctx, logger, bandit))
+		r.Get("/exposure", handlers.NewGetExposureReport(ctx, logger, slateRepository, exposure))
+	})
+
 	swaggerURL := fmt.Sprintf("http://%s/swagger/doc.json", address)
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL(swaggerURL),
@@ -109,3 +290,15 @@ func main() {
 	}
 
 }
+
+func experimentsFromConfig(cfg []config.Experiment) []experiment.Experiment {
+	experiments := make([]experiment.Experiment, 0, len(cfg))
+	for _, e := range cfg {
+		variants := make([]experiment.Variant, 0, len(e.Variants))
+		for _, v := range e.Variants {
+			variants = append(variants, experiment.Variant{Name: v.Name, Strategy: v.Strategy, Weight: v.Weight})
+		}
+		experiments = append(experiments, experiment.Experiment{Name: e.Name, Enabled: e.Enabled, Variants: variants})
+	}
+	return experiments
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/danyatalent/movie-recommend/internal/config"
+	events "github.com/danyatalent/movie-recommend/internal/events/db"
+	rating "github.com/danyatalent/movie-recommend/internal/rating/db"
+	"github.com/danyatalent/movie-recommend/internal/recommend"
+	model "github.com/danyatalent/movie-recommend/internal/recommend/db"
+	watchlist "github.com/danyatalent/movie-recommend/internal/watchlist/db"
+	"github.com/danyatalent/movie-recommend/pkg/client/postgresql"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/joho/godotenv"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Flags must be defined before config.GetConfig parses command line
+var (
+	factors    = flag.Int("factors", 20, "number of latent factors")
+	iterations = flag.Int("iterations", 15, "number of ALS iterations")
+	lambda     = flag.Float64("lambda", 0.1, "regularization")
+	holdout    = flag.Float64("holdout", 0.1, "fraction of ratings held out to compute test RMSE before refitting on all, 0 to skip evaluation")
+	seed       = flag.Int64("seed", 42, "random seed")
+	rollback   = flag.Bool("rollback", false, "deactivate active model instead of training, previous version becomes active")
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Fatal(err)
+	}
+	cfg := config.GetConfig()
+	ctx := context.Background()
+	logger := logging.InitLogger(cfg.LogLevel)
+
+	postgresPool, err := postgresql.NewClient(logger, ctx, 3, cfg.Storage)
+	if err != nil {
+		logger.Error("cannot connect to postgres", logging.Err(err))
+		os.Exit(1)
+	}
+	defer postgresPool.Close()
+	modelRepository := model.NewRepository(postgresPool, logger)
+
+	if *rollback {
+		version, err := modelRepository.RollbackModel(ctx)
+		if err != nil {
+			logger.Error("cannot rollback model", logging.Err(err))
+			os.Exit(1)
+		}
+		logger.Info("model rolled back", slog.String("version", version))
+		return
+	}
+
+	// explicit ratings are merged with preferences inferred from watch events and watchlist
+	feedback := recommend.NewFeedback(rating.NewRepository(postgresPool, logger),
+		events.NewRepository(postgresPool, logger), watchlist.NewRepository(postgresPool, logger))
+	ratings, err := feedback.GetAllRatings(ctx)
+	if err != nil {
+		logger.Error("cannot load ratings", logging.Err(err))
+		os.Exit(1)
+	}
+	train, test := recommend.SplitRandom(ratings, *holdout, *seed)
+
+	start := time.Now()
+	m := recommend.TrainALS(recommend.NewDataset(train), recommend.ALSParams{
+		Factors:    *factors,
+		Iterations: *iterations,
+		Lambda:     *lambda,
+		Seed:       *seed,
+	})
+	if len(test) > 0 {
+		// held out ratings are only for evaluation, saved model is refitted on all of them
+		testRMSE := m.RMSE(recommend.NewDataset(test))
+		logger.Info("model evaluated", slog.Duration("took", time.Since(start)), slog.Float64("test_rmse", testRMSE))
+		m = recommend.TrainALS(recommend.NewDataset(ratings), m.Params)
+		m.Metrics.TestRMSE = testRMSE
+	}
+	logger.Info("model trained",
+		slog.Duration("took", time.Since(start)),
+		slog.Any("metrics", m.Metrics),
+	)
+
+	version, err := modelRepository.SaveModel(ctx, m)
+	if err != nil {
+		logger.Error("cannot save model", logging.Err(err))
+		os.Exit(1)
+	}
+	logger.Info("model saved", slog.String("version", version))
+}
@@ -14,8 +14,10 @@ create table movies (
     name varchar(50) not null,
     description text,
     duration integer,
-    rating numeric(3, 1),
-    director_id uuid not null references directors(id)
+    rating numeric(3, 1) not null default 0,
+    year integer,
+    director_id uuid not null references directors(id),
+    created_at timestamp not null default now()
 );
 
 create table genres(
@@ -36,3 +38,127 @@ create table users (
     password text not null,
     email varchar(50) not null unique
 );
+
+create table ratings (
+    user_id uuid not null references users(id) on delete cascade,
+    movie_id uuid not null references movies(id) on delete cascade,
+    score smallint not null check (score between 1 and 10),
+    rated_at timestamp not null default now(),
+    constraint pk_ratings primary key (user_id, movie_id)
+);
+
+create index idx_ratings_movie_id on ratings(movie_id);
+
+create table models (
+    id uuid default uuid_generate_v4() primary key,
+    algorithm varchar(20) not null,
+    factors integer not null,
+    iterations integer not null,
+    lambda double precision not null,
+    global_mean double precision not null,
+    train_rmse double precision not null,
+    test_rmse double precision not null default 0,
+    ratings integer not null,
+    active bool not null default true,
+    created_at timestamp not null default now()
+);
+
+create table model_user_factors (
+    model_id uuid not null references models(id) on delete cascade,
+    user_id uuid not null,
+    factors double precision[] not null,
+    constraint pk_model_user_factors primary key (model_id, user_id)
+);
+
+create table model_item_factors (
+    model_id uuid not null references models(id) on delete cascade,
+    movie_id uuid not null,
+    factors double precision[] not null,
+    constraint pk_model_item_factors primary key (model_id, movie_id)
+);
+
+create table user_genre_preferences (
+    user_id uuid not null references users(id) on delete cascade,
+    genre_id uuid not null references genres(id) on delete cascade,
+    created_at timestamp not null default now(),
+    constraint pk_user_genre_preferences primary key (user_id, genre_id)
+);
+
+create table user_movie_preferences (
+    user_id uuid not null references users(id) on delete cascade,
+    movie_id uuid not null references movies(id) on delete cascade,
+    created_at timestamp not null default now(),
+    constraint pk_user_movie_preferences primary key (user_id, movie_id)
+);
+
+create table events (
+    id bigserial primary key,
+    user_id uuid not null references users(id) on delete cascade,
+    movie_id uuid not null references movies(id) on delete cascade,
+    type varchar(20) not null check (type in ('impression', 'click', 'play', 'progress', 'complete')),
+    progress smallint not null default 0 check (progress between 0 and 100),
+    experiment varchar(100),
+    variant varchar(100),
+    occurred_at timestamp not null default now()
+);
+
+create index idx_events_user_movie on events(user_id, movie_id);
+create index idx_events_occurred_at on events(occurred_at);
+create index idx_events_experiment on events(experiment, variant) where experiment is not null;
+
+create table session_views (
+    id bigserial primary key,
+    token varchar(64) not null,
+    movie_id uuid not null references movies(id) on delete cascade,
+    viewed_at timestamp not null default now()
+);
+
+create index idx_session_views_token on session_views(token, viewed_at);
+create index idx_session_views_viewed_at on session_views(viewed_at);
+
+create table watchlist (
+    user_id uuid not null references users(id) on delete cascade,
+    movie_id uuid not null references movies(id) on delete cascade,
+    added_at timestamp not null default now(),
+    constraint pk_watchlist primary key (user_id, movie_id)
+);
+
+create table exclusions (
+    user_id uuid not null references users(id) on delete cascade,
+    kind varchar(10) not null check (kind in ('movie', 'genre', 'director')),
+    target_id uuid not null,
+    created_at timestamp not null default now(),
+    constraint pk_exclusions primary key (user_id, kind, target_id)
+);
+
+create table recommendation_slates (
+    id uuid default uuid_generate_v4() primary key,
+    user_id uuid not null references users(id) on delete cascade,
+    strategy varchar(20) not null,
+    experiment varchar(100),
+    variant varchar(100),
+    served_at timestamp not null default now()
+);
+
+create index idx_recommendation_slates_served_at on recommendation_slates(served_at);
+
+create table recommendation_slate_items (
+    slate_id uuid not null references recommendation_slates(id) on delete cascade,
+    movie_id uuid not null references movies(id) on delete cascade,
+    position smallint not null,
+    score double precision not null,
+    exploration bool not null default false,
+    constraint pk_recommendation_slate_items primary key (slate_id, movie_id)
+);
+
+create table recommendation_feedback (
+    id bigserial primary key,
+    slate_id uuid not null,
+    movie_id uuid not null,
+    action varchar(10) not null check (action in ('click', 'dismiss')),
+    created_at timestamp not null default now(),
+    constraint fk_recommendation_feedback_item foreign key (slate_id, movie_id)
+        references recommendation_slate_items(slate_id, movie_id) on delete cascade
+);
+
+create index idx_recommendation_feedback_slate on recommendation_feedback(slate_id, movie_id);
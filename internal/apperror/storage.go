@@ -8,4 +8,5 @@ var (
 	ErrEntityNotFound       = errors.New("entity not found")
 	ErrEntityExists         = errors.New("entity exists")
 	ErrConstraintUniqueCode = "23505"
+	ErrForeignKeyCode       = "23503"
 )
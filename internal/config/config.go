@@ -14,6 +14,12 @@ type Config struct {
 	LogLevel   string `yaml:"log_level" env-default:"info"`
 	HTTPServer `yaml:"http_server"`
 	Storage    `yaml:"storage"`
+	Recommend  `yaml:"recommend"`
+	Events     `yaml:"events"`
+	Trending   `yaml:"trending"`
+	Sessions   `yaml:"sessions"`
+	// Experiments split recommendation traffic between strategies, at most one can be enabled
+	Experiments []Experiment `yaml:"experiments"`
 }
 
 type HTTPServer struct {
@@ -30,18 +36,152 @@ type Storage struct {
 	Password string `yaml:"password" env-required:"true" env:"DB_PASSWORD"`
 }
 
+type Recommend struct {
+	// Strategy is "item-cf", "als" or "hybrid", als model is trained by cmd/train
+	Strategy          string        `yaml:"strategy" env-default:"item-cf"`
+	RefreshInterval   time.Duration `yaml:"refresh_interval" env-default:"5m"`
+	ModelPollInterval time.Duration `yaml:"model_poll_interval" env-default:"1m"`
+	Neighbours        int           `yaml:"neighbours" env-default:"50"`
+	// MinRatings is how many ratings user needs before onboarding picks stop seeding recommendations
+	MinRatings  int         `yaml:"min_ratings" env-default:"5"`
+	Diversity   Diversity   `yaml:"diversity"`
+	Cache       Cache       `yaml:"cache"`
+	Exploration Exploration `yaml:"exploration"`
+	Hybrid      Hybrid      `yaml:"hybrid"`
+	Taste       Taste       `yaml:"taste"`
+	Fairness    Fairness    `yaml:"fairness"`
+}
+
+// Fairness configures re-ranking toward target shares of exposure of directors and countries
+type Fairness struct {
+	// MinNonOscarShare is the least share of exposure of directors without Oscar
+	MinNonOscarShare float64 `yaml:"min_non_oscar_share" env-default:"0.3"`
+	// MaxDirectorShare and MaxCountryShare cap share of one director and country, 0 means no cap
+	MaxDirectorShare float64 `yaml:"max_director_share" env-default:"0.05"`
+	MaxCountryShare  float64 `yaml:"max_country_share" env-default:"0.5"`
+	// Strength is how much scores are moved toward targets, 0 disables re-ranking
+	Strength float64 `yaml:"strength" env-default:"0.3"`
+	// Window is how far back served slates count as exposure
+	Window          time.Duration `yaml:"window" env-default:"720h"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" env-default:"5m"`
+}
+
+// Taste configures taste profiles of users
+type Taste struct {
+	// HalfLife is age at which feedback weighs half as much as fresh one
+	HalfLife time.Duration `yaml:"half_life" env-default:"2160h"`
+	// RefreshInterval is how often profiles of users with new feedback are updated
+	RefreshInterval time.Duration `yaml:"refresh_interval" env-default:"30s"`
+	// RebuildInterval is how often all profiles are rebuilt, it covers deleted feedback
+	RebuildInterval time.Duration `yaml:"rebuild_interval" env-default:"1h"`
+	TopDirectors    int           `yaml:"top_directors" env-default:"5"`
+}
+
+// Hybrid configures blend of collaborative, content and popularity scores, weights of new users
+// move to weights of heavy users as they rate up to HeavyRatings movies
+type Hybrid struct {
+	HeavyRatings           int     `yaml:"heavy_ratings" env-default:"20"`
+	NewUserCollaborative   float64 `yaml:"new_user_collaborative" env-default:"0.1"`
+	NewUserContent         float64 `yaml:"new_user_content" env-default:"0.2"`
+	NewUserPopularity      float64 `yaml:"new_user_popularity" env-default:"0.7"`
+	HeavyUserCollaborative float64 `yaml:"heavy_user_collaborative" env-default:"0.7"`
+	HeavyUserContent       float64 `yaml:"heavy_user_content" env-default:"0.2"`
+	HeavyUserPopularity    float64 `yaml:"heavy_user_popularity" env-default:"0.1"`
+}
+
+// Exploration configures bandit putting movies with uncertain click rate into slates
+type Exploration struct {
+	// Slots is number of positions of every slate reserved for exploration, 0 disables it
+	Slots int `yaml:"slots" env-default:"2"`
+	// PriorClicks out of PriorImpressions is click rate assumed for movie never served
+	PriorClicks      float64 `yaml:"prior_clicks" env-default:"1"`
+	PriorImpressions float64 `yaml:"prior_impressions" env-default:"20"`
+	// NewMovieBoost multiplies prior clicks of movies created within NewMovieWindow
+	NewMovieBoost  float64       `yaml:"new_movie_boost" env-default:"3"`
+	NewMovieWindow time.Duration `yaml:"new_movie_window" env-default:"168h"`
+	// PoolSize caps movies sampled for every slate, new and least served movies are kept
+	PoolSize        int           `yaml:"pool_size" env-default:"500"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" env-default:"1m"`
+}
+
+// Cache configures precomputed recommendations
+type Cache struct {
+	// Size is number of candidates kept per user, it must cover the largest page with spare for re-ranking
+	Size int `yaml:"size" env-default:"300"`
+	// Users caps number of users kept, least recently served are evicted first
+	Users           int           `yaml:"users" env-default:"100000"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" env-default:"1m"`
+	// MaxAge is how long list of inactive user is served before it is recomputed
+	MaxAge time.Duration `yaml:"max_age" env-default:"1h"`
+	// MaxIdle is how long list of user who doesn't request recommendations is kept
+	MaxIdle time.Duration `yaml:"max_idle" env-default:"72h"`
+	// ActiveWindow is how far back activity is looked for to warm cache on start
+	ActiveWindow time.Duration `yaml:"active_window" env-default:"720h"`
+}
+
+// Diversity configures re-ranking of recommendations, every value can be overridden per request
+type Diversity struct {
+	// Lambda in [0, 1] weighs relevance against diversity, 1 disables re-ranking
+	Lambda float64 `yaml:"lambda" env-default:"0.7"`
+	// MaxPerDirector and MaxPerGenre cap movies of one director or genre in list, 0 means no cap
+	MaxPerDirector int `yaml:"max_per_director" env-default:"3"`
+	MaxPerGenre    int `yaml:"max_per_genre" env-default:"0"`
+}
+
+type Experiment struct {
+	Name     string              `yaml:"name"`
+	Enabled  bool                `yaml:"enabled"`
+	Variants []ExperimentVariant `yaml:"variants"`
+}
+
+type ExperimentVariant struct {
+	Name string `yaml:"name"`
+	// Strategy is one of "item-cf", "als", "hybrid" or "popular"
+	Strategy string `yaml:"strategy"`
+	Weight   int    `yaml:"weight"`
+}
+
+type Events struct {
+	BatchSize     int           `yaml:"batch_size" env-default:"500"`
+	QueueSize     int           `yaml:"queue_size" env-default:"10000"`
+	FlushInterval time.Duration `yaml:"flush_interval" env-default:"1s"`
+}
+
+type Trending struct {
+	RefreshInterval time.Duration `yaml:"refresh_interval" env-default:"1m"`
+	// MinVotes is m of bayesian weighted rating, votes needed to trust movie's own mean
+	MinVotes int `yaml:"min_votes" env-default:"10"`
+}
+
+// Sessions configures next movie recommendations of anonymous sessions
+type Sessions struct {
+	RefreshInterval time.Duration `yaml:"refresh_interval" env-default:"5m"`
+	// Window is how far back transitions between movies are mined
+	Window time.Duration `yaml:"window" env-default:"720h"`
+	// Gap splits views of one user or session into different sessions
+	Gap time.Duration `yaml:"gap" env-default:"3h"`
+}
+
 func GetConfig() *Config {
 	pathToConfig := fetchConfigPath()
 	if _, err := os.Stat(pathToConfig); os.IsNotExist(err) {
 		log.Fatalf("file does not exist: %v", err)
 	}
-	var cfg Config
-
-	if err := cleanenv.ReadConfig(pathToConfig, &cfg); err != nil {
+	cfg, err := ReadConfig(pathToConfig)
+	if err != nil {
 		log.Fatalf("can't parse config: %v", err)
 	}
 
-	return &cfg
+	return cfg
+}
+
+// ReadConfig parses config without touching command line, for tools defining their own flags
+func ReadConfig(pathToConfig string) (*Config, error) {
+	var cfg Config
+	if err := cleanenv.ReadConfig(pathToConfig, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
 }
 
 func fetchConfigPath() string {
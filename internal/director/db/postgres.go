@@ -69,3 +69,27 @@ func (r *Repository) GetDirectorByID(ctx context.Context, id string) (director.D
 	//d.BirthDate = director.CustomDate{Time: date}
 	return d, nil
 }
+
+// GetAllDirectors returns every director, used to build in-memory catalog
+func (r *Repository) GetAllDirectors(ctx context.Context) ([]director.Director, error) {
+	q := "select id, first_name, last_name, country, birth_date::text, has_oscar from directors"
+	r.logger.Debug("getting all directors", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	directors := make([]director.Director, 0)
+	for rows.Next() {
+		var d director.Director
+		if err = rows.Scan(&d.ID, &d.FirstName, &d.LastName, &d.Country, &d.BirthDate, &d.HasOscar); err != nil {
+			return nil, err
+		}
+		directors = append(directors, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return directors, nil
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"context"
+	"errors"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var ErrQueueFull = errors.New("events queue is full")
+
+type Saver interface {
+	SaveEvents(ctx context.Context, events []Event) error
+}
+
+// Batcher queues events and writes them in batches of size or every interval, whichever comes first
+type Batcher struct {
+	saver    Saver
+	logger   *slog.Logger
+	size     int
+	interval time.Duration
+
+	mu    sync.Mutex
+	queue chan Event
+}
+
+func NewBatcher(saver Saver, logger *slog.Logger, size, capacity int, interval time.Duration) *Batcher {
+	return &Batcher{
+		saver:    saver,
+		logger:   logger,
+		size:     size,
+		interval: interval,
+		queue:    make(chan Event, capacity),
+	}
+}
+
+// Add queues all events or none of them when queue has no room
+func (b *Batcher) Add(events []Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if cap(b.queue)-len(b.queue) < len(events) {
+		return ErrQueueFull
+	}
+	now := time.Now()
+	for _, e := range events {
+		if e.OccurredAt.IsZero() {
+			e.OccurredAt = now
+		}
+		b.queue <- e
+	}
+	return nil
+}
+
+// Run writes queued events until ctx is done, then flushes what is left
+func (b *Batcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	batch := make([]Event, 0, b.size)
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case e := <-b.queue:
+					batch = append(batch, e)
+				default:
+					b.flush(context.Background(), batch)
+					return
+				}
+			}
+		case e := <-b.queue:
+			batch = append(batch, e)
+			if len(batch) >= b.size {
+				b.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			b.flush(ctx, batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (b *Batcher) flush(ctx context.Context, batch []Event) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := b.saver.SaveEvents(ctx, batch); err != nil {
+		b.logger.Error("failed to save events", slog.Int("count", len(batch)), logging.Err(err))
+		return
+	}
+	b.logger.Debug("events saved", slog.Int("count", len(batch)))
+}
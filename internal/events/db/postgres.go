@@ -0,0 +1,141 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danyatalent/movie-recommend/internal/events"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"github.com/danyatalent/movie-recommend/pkg/client/postgresql"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"log/slog"
+	"time"
+)
+
+type Repository struct {
+	client postgresql.Client
+	logger *slog.Logger
+}
+
+func NewRepository(client postgresql.Client, logger *slog.Logger) *Repository {
+	return &Repository{
+		client: client,
+		logger: logger,
+	}
+}
+
+// SaveEvents inserts batch in one statement, events of unknown users or movies are dropped
+// instead of failing the whole batch
+func (r *Repository) SaveEvents(ctx context.Context, batch []events.Event) error {
+	q := `insert into events(user_id, movie_id, type, progress, experiment, variant, occurred_at)
+		  select e.user_id, e.movie_id, e.type, e.progress, nullif(e.experiment, ''), nullif(e.variant, ''), e.occurred_at
+		  from unnest($1::uuid[], $2::uuid[], $3::varchar[], $4::smallint[], $5::varchar[], $6::varchar[],
+					  $7::timestamp[])
+			   as e(user_id, movie_id, type, progress, experiment, variant, occurred_at)
+		  where exists(select 1 from users u where u.id = e.user_id)
+			and exists(select 1 from movies m where m.id = e.movie_id)`
+	users := make([]string, len(batch))
+	movies := make([]string, len(batch))
+	types := make([]string, len(batch))
+	progress := make([]int16, len(batch))
+	experiments := make([]string, len(batch))
+	variants := make([]string, len(batch))
+	occurred := make([]time.Time, len(batch))
+	for i, e := range batch {
+		users[i], movies[i], types[i], progress[i], occurred[i] = e.UserID, e.MovieID, e.Type, int16(e.Progress), e.OccurredAt
+		experiments[i], variants[i] = e.Experiment, e.Variant
+	}
+	result, err := r.client.Exec(ctx, q, users, movies, types, progress, experiments, variants, occurred)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Code: %s, SQLState: %s",
+				pgErr.Message, pgErr.Detail, pgErr.Code, pgErr.SQLState()))
+			r.logger.Error("error due query", logging.Err(newErr))
+			return newErr
+		}
+		return err
+	}
+	if dropped := int64(len(batch)) - result.RowsAffected(); dropped > 0 {
+		r.logger.Warn("events of unknown users or movies dropped", slog.Int64("count", dropped))
+	}
+	return nil
+}
+
+// GetImplicitRatings aggregates events of every user with every movie into implicit scores
+func (r *Repository) GetImplicitRatings(ctx context.Context) ([]rating.Rating, error) {
+	q := fmt.Sprintf(interactionsQuery, "")
+	r.logger.Debug("getting implicit ratings", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return collectPreferences(rows)
+}
+
+// GetUserImplicitRatings aggregates events of user into implicit scores
+func (r *Repository) GetUserImplicitRatings(ctx context.Context, userID string) ([]rating.Rating, error) {
+	q := fmt.Sprintf(interactionsQuery, "where user_id = $1")
+	r.logger.Debug("getting implicit ratings of user", slog.String("user_id", userID))
+	rows, err := r.client.Query(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	return collectPreferences(rows)
+}
+
+// GetUserInteractions returns events of user aggregated by movie, most recent first
+func (r *Repository) GetUserInteractions(ctx context.Context, userID string) ([]events.Interactions, error) {
+	q := fmt.Sprintf(interactionsQuery, "where user_id = $1") + " order by max(occurred_at) desc"
+	r.logger.Debug("getting interactions of user", slog.String("user_id", userID))
+	rows, err := r.client.Query(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	return collectInteractions(rows)
+}
+
+// interactionsQuery aggregates events by user and movie, %s is where clause
+const interactionsQuery = `select user_id, movie_id,
+		 count(*) filter (where type = 'impression'),
+		 count(*) filter (where type = 'click'),
+		 count(*) filter (where type = 'play'),
+		 count(*) filter (where type = 'complete'),
+		 coalesce(max(progress) filter (where type = 'progress'), 0),
+		 max(occurred_at)
+	  from events
+	  %s
+	  group by user_id, movie_id`
+
+func collectPreferences(rows pgx.Rows) ([]rating.Rating, error) {
+	interactions, err := collectInteractions(rows)
+	if err != nil {
+		return nil, err
+	}
+	ratings := make([]rating.Rating, 0, len(interactions))
+	for _, i := range interactions {
+		if rt, ok := i.Preference(); ok {
+			ratings = append(ratings, rt)
+		}
+	}
+	return ratings, nil
+}
+
+func collectInteractions(rows pgx.Rows) ([]events.Interactions, error) {
+	defer rows.Close()
+	interactions := make([]events.Interactions, 0)
+	for rows.Next() {
+		var i events.Interactions
+		err := rows.Scan(&i.UserID, &i.MovieID, &i.Impressions, &i.Clicks, &i.Plays, &i.Completes, &i.MaxProgress, &i.LastAt)
+		if err != nil {
+			return nil, err
+		}
+		interactions = append(interactions, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return interactions, nil
+}
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"math"
+	"time"
+)
+
+const (
+	TypeImpression = "impression"
+	TypeClick      = "click"
+	TypePlay       = "play"
+	TypeProgress   = "progress"
+	TypeComplete   = "complete"
+)
+
+type Event struct {
+	UserID   string `json:"user_id" example:"a9aec972-2c52-441a-8f17-79506cd34366"`
+	MovieID  string `json:"movie_id" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+	Type     string `json:"type" example:"progress"`
+	Progress int    `json:"progress" example:"40"`
+	// Experiment and Variant tag events caused by recommendations served within experiment
+	Experiment string    `json:"experiment,omitempty" example:"als-vs-item-cf"`
+	Variant    string    `json:"variant,omitempty" example:"treatment"`
+	OccurredAt time.Time `json:"occurred_at" example:"2024-03-17T12:00:00Z"`
+}
+
+// Interactions are events of one user with one movie aggregated by type
+type Interactions struct {
+	UserID      string
+	MovieID     string
+	Impressions int
+	Clicks      int
+	Plays       int
+	Completes   int
+	MaxProgress int
+	LastAt      time.Time
+}
+
+// InProgress is true when user started the movie and hasn't finished it yet
+func (i Interactions) InProgress() bool {
+	return i.Completes == 0 && (i.Plays > 0 || i.MaxProgress > 0)
+}
+
+// Watched is true when user finished the movie at least once
+func (i Interactions) Watched() bool {
+	return i.Completes > 0
+}
+
+// Preference turns interactions into implicit score on rating scale,
+// false when user only saw the movie and showed no interest
+func (i Interactions) Preference() (rating.Rating, bool) {
+	var score float64
+	switch {
+	case i.Completes > 0:
+		score = 9
+	case i.MaxProgress > 0:
+		score = 5 + 4*float64(i.MaxProgress)/100
+	case i.Plays > 0:
+		score = 5
+	case i.Clicks > 0:
+		score = 4
+	default:
+		return rating.Rating{}, false
+	}
+	// rewatching is the strongest implicit signal
+	if i.Plays > 1 || i.Completes > 1 {
+		score++
+	}
+	return rating.Rating{
+		UserID:  i.UserID,
+		MovieID: i.MovieID,
+		Score:   int(math.Min(math.Round(score), rating.MaxScore)),
+		RatedAt: i.LastAt,
+	}, true
+}
@@ -0,0 +1,31 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestInteractions_Preference(t *testing.T) {
+	tests := []struct {
+		name         string
+		interactions Interactions
+		score        int
+		ok           bool
+	}{
+		{name: "impression only", interactions: Interactions{Impressions: 3}, ok: false},
+		{name: "click", interactions: Interactions{Impressions: 1, Clicks: 1}, score: 4, ok: true},
+		{name: "half watched", interactions: Interactions{Plays: 1, MaxProgress: 50}, score: 7, ok: true},
+		{name: "completed", interactions: Interactions{Plays: 1, Completes: 1, MaxProgress: 100}, score: 9, ok: true},
+		{name: "rewatched", interactions: Interactions{Plays: 2, Completes: 2, MaxProgress: 100}, score: 10, ok: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt, ok := tt.interactions.Preference()
+			if ok != tt.ok {
+				t.Fatalf("expected ok=%v, got %v", tt.ok, ok)
+			}
+			if ok && rt.Score != tt.score {
+				t.Errorf("expected score %d, got %d", tt.score, rt.Score)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package exclusion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/exclusion"
+	"github.com/danyatalent/movie-recommend/pkg/client/postgresql"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"log/slog"
+)
+
+type Repository struct {
+	client postgresql.Client
+	logger *slog.Logger
+}
+
+func NewRepository(client postgresql.Client, logger *slog.Logger) *Repository {
+	return &Repository{
+		client: client,
+		logger: logger,
+	}
+}
+
+// targets maps exclusion kind to table its target is stored in
+var targets = map[string]string{
+	exclusion.KindMovie:    "movies",
+	exclusion.KindGenre:    "genres",
+	exclusion.KindDirector: "directors",
+}
+
+// AddExclusion is idempotent, excluding the same target again keeps original date
+func (r *Repository) AddExclusion(ctx context.Context, e *exclusion.Exclusion) error {
+	table, ok := targets[e.Kind]
+	if !ok {
+		return fmt.Errorf("unknown exclusion kind: %s", e.Kind)
+	}
+	// target_id can't reference three tables, so its existence is checked by the insert itself
+	q := fmt.Sprintf(`insert into exclusions(user_id, kind, target_id)
+		  select $1::uuid, $2::varchar, $3::uuid where exists (select 1 from %s where id = $3::uuid)
+		  on conflict (user_id, kind, target_id) do update set kind = excluded.kind
+		  returning created_at`, table)
+	r.logger.Info("adding exclusion", slog.String("query", q))
+	if err := r.client.QueryRow(ctx, q, e.UserID, e.Kind, e.TargetID).Scan(&e.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperror.ErrEntityNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.SQLState() == apperror.ErrForeignKeyCode {
+				return apperror.ErrEntityNotFound
+			}
+			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Code: %s, SQLState: %s",
+				pgErr.Message, pgErr.Detail, pgErr.Code, pgErr.SQLState()))
+			r.logger.Error("error due query", logging.Err(newErr))
+			return newErr
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) RemoveExclusion(ctx context.Context, userID, kind, targetID string) error {
+	q := "delete from exclusions where user_id=$1 and kind=$2 and target_id=$3"
+	r.logger.Info("removing exclusion", slog.String("query", q))
+	result, err := r.client.Exec(ctx, q, userID, kind, targetID)
+	if err != nil {
+		return fmt.Errorf("can't remove exclusion: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return apperror.ErrEntityNotFound
+	}
+	return nil
+}
+
+func (r *Repository) GetExclusions(ctx context.Context, userID string) ([]exclusion.Exclusion, error) {
+	q := "select user_id, kind, target_id, created_at from exclusions where user_id=$1 order by created_at desc"
+	r.logger.Debug("getting exclusions", slog.String("user_id", userID))
+	rows, err := r.client.Query(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	exclusions := make([]exclusion.Exclusion, 0)
+	for rows.Next() {
+		var e exclusion.Exclusion
+		if err = rows.Scan(&e.UserID, &e.Kind, &e.TargetID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		exclusions = append(exclusions, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return exclusions, nil
+}
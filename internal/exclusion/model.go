@@ -0,0 +1,69 @@
+package exclusion
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"time"
+)
+
+const (
+	KindMovie    = "movie"
+	KindGenre    = "genre"
+	KindDirector = "director"
+)
+
+// Exclusion marks movie, genre or director user is not interested in
+type Exclusion struct {
+	UserID    string    `json:"user_id" example:"a9aec972-2c52-441a-8f17-79506cd34366"`
+	Kind      string    `json:"kind" example:"genre"`
+	TargetID  string    `json:"target_id" example:"59457b31-89f8-4ade-b46c-731c61430c3e"`
+	CreatedAt time.Time `json:"created_at" example:"2024-03-17T12:00:00Z"`
+}
+
+type Source interface {
+	GetExclusions(ctx context.Context, userID string) ([]Exclusion, error)
+}
+
+// Set answers whether movie is hidden by any exclusion of user
+type Set map[string]map[string]struct{}
+
+func NewSet(exclusions []Exclusion) Set {
+	s := make(Set)
+	for _, e := range exclusions {
+		if s[e.Kind] == nil {
+			s[e.Kind] = make(map[string]struct{})
+		}
+		s[e.Kind][e.TargetID] = struct{}{}
+	}
+	return s
+}
+
+// Load returns exclusions of user, empty set for anonymous request
+func Load(ctx context.Context, source Source, userID string) (Set, error) {
+	if userID == "" {
+		return Set{}, nil
+	}
+	exclusions, err := source.GetExclusions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return NewSet(exclusions), nil
+}
+
+func (s Set) Excludes(m movie.Movie) bool {
+	if len(s) == 0 {
+		return false
+	}
+	if _, ok := s[KindMovie][m.ID]; ok {
+		return true
+	}
+	if _, ok := s[KindDirector][m.DirectorID]; ok {
+		return true
+	}
+	for _, g := range m.Genres {
+		if _, ok := s[KindGenre][g.ID]; ok {
+			return true
+		}
+	}
+	return false
+}
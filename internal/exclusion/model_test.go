@@ -0,0 +1,32 @@
+package exclusion
+
+import (
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"testing"
+)
+
+func TestSet_Excludes(t *testing.T) {
+	set := NewSet([]Exclusion{
+		{Kind: KindMovie, TargetID: "m1"},
+		{Kind: KindGenre, TargetID: "horror"},
+		{Kind: KindDirector, TargetID: "d1"},
+	})
+	tests := []struct {
+		name     string
+		movie    movie.Movie
+		excluded bool
+	}{
+		{name: "excluded movie", movie: movie.Movie{ID: "m1", DirectorID: "d2"}, excluded: true},
+		{name: "excluded director", movie: movie.Movie{ID: "m2", DirectorID: "d1"}, excluded: true},
+		{name: "excluded genre", movie: movie.Movie{ID: "m3", DirectorID: "d2", Genres: []genre.Genre{{ID: "drama"}, {ID: "horror"}}}, excluded: true},
+		{name: "allowed", movie: movie.Movie{ID: "m4", DirectorID: "d2", Genres: []genre.Genre{{ID: "drama"}}}, excluded: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := set.Excludes(tt.movie); got != tt.excluded {
+				t.Errorf("expected excluded=%v, got %v", tt.excluded, got)
+			}
+		})
+	}
+}
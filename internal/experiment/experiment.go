@@ -0,0 +1,110 @@
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+)
+
+var ErrInvalidExperiment = errors.New("invalid experiment")
+
+// Experiments holds configured experiments, only one of them can be enabled at a time
+// because every variant replaces the same recommender strategy
+type Experiments struct {
+	all    []Experiment
+	active *Experiment
+}
+
+func New(experiments []Experiment) (*Experiments, error) {
+	e := &Experiments{all: experiments}
+	names := make(map[string]struct{}, len(experiments))
+	for i, exp := range experiments {
+		if exp.Name == "" {
+			return nil, fmt.Errorf("%w: experiment without name", ErrInvalidExperiment)
+		}
+		if _, ok := names[exp.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate experiment %s", ErrInvalidExperiment, exp.Name)
+		}
+		names[exp.Name] = struct{}{}
+		if err := validate(exp); err != nil {
+			return nil, err
+		}
+		if !exp.Enabled {
+			continue
+		}
+		if e.active != nil {
+			return nil, fmt.Errorf("%w: both %s and %s are enabled", ErrInvalidExperiment, e.active.Name, exp.Name)
+		}
+		e.active = &experiments[i]
+	}
+	return e, nil
+}
+
+func validate(exp Experiment) error {
+	if len(exp.Variants) == 0 {
+		return fmt.Errorf("%w: %s has no variants", ErrInvalidExperiment, exp.Name)
+	}
+	variants := make(map[string]struct{}, len(exp.Variants))
+	for _, v := range exp.Variants {
+		if v.Name == "" || v.Strategy == "" {
+			return fmt.Errorf("%w: %s has variant without name or strategy", ErrInvalidExperiment, exp.Name)
+		}
+		if _, ok := variants[v.Name]; ok {
+			return fmt.Errorf("%w: %s has duplicate variant %s", ErrInvalidExperiment, exp.Name, v.Name)
+		}
+		variants[v.Name] = struct{}{}
+		if v.Weight <= 0 {
+			return fmt.Errorf("%w: variant %s of %s must have positive weight", ErrInvalidExperiment, v.Name, exp.Name)
+		}
+	}
+	return nil
+}
+
+// All returns every configured experiment, enabled or not
+func (e *Experiments) All() []Experiment {
+	return e.all
+}
+
+// Strategies returns strategies used by variants of enabled experiment
+func (e *Experiments) Strategies() []string {
+	if e.active == nil {
+		return nil
+	}
+	strategies := make([]string, 0, len(e.active.Variants))
+	for _, v := range e.active.Variants {
+		strategies = append(strategies, v.Strategy)
+	}
+	return strategies
+}
+
+// Assign returns variant of enabled experiment for user, false if no experiment is running
+func (e *Experiments) Assign(userID string) (Assignment, bool) {
+	if e.active == nil {
+		return Assignment{}, false
+	}
+	v := Pick(*e.active, userID)
+	return Assignment{
+		Experiment: e.active.Name,
+		Variant:    v.Name,
+		Strategy:   v.Strategy,
+	}, true
+}
+
+// Pick hashes experiment name with user id into weighted buckets, so user stays in the same variant
+// while weights don't change and assignments of different experiments are independent
+func Pick(exp Experiment, userID string) Variant {
+	var total int
+	for _, v := range exp.Variants {
+		total += v.Weight
+	}
+	h := fnv.New64a()
+	h.Write([]byte(exp.Name + ":" + userID))
+	bucket := int(h.Sum64() % uint64(total))
+	for _, v := range exp.Variants {
+		if bucket < v.Weight {
+			return v
+		}
+		bucket -= v.Weight
+	}
+	return exp.Variants[len(exp.Variants)-1]
+}
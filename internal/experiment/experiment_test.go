@@ -0,0 +1,53 @@
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestPick(t *testing.T) {
+	exp := Experiment{Name: "als-vs-item-cf", Variants: []Variant{
+		{Name: "control", Strategy: "item-cf", Weight: 80},
+		{Name: "treatment", Strategy: "als", Weight: 20},
+	}}
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		v := Pick(exp, userID)
+		if again := Pick(exp, userID); again.Name != v.Name {
+			t.Fatalf("%s assigned to %s and then to %s", userID, v.Name, again.Name)
+		}
+		counts[v.Name]++
+	}
+	if share := float64(counts["treatment"]) / 10000; math.Abs(share-0.2) > 0.02 {
+		t.Errorf("expected 20%% of users in treatment, got %.1f%%", share*100)
+	}
+}
+
+func TestNew(t *testing.T) {
+	variants := []Variant{{Name: "control", Strategy: "item-cf", Weight: 1}}
+	tests := []struct {
+		name        string
+		experiments []Experiment
+		valid       bool
+	}{
+		{name: "single enabled", experiments: []Experiment{{Name: "a", Enabled: true, Variants: variants}, {Name: "b", Variants: variants}}, valid: true},
+		{name: "two enabled", experiments: []Experiment{{Name: "a", Enabled: true, Variants: variants}, {Name: "b", Enabled: true, Variants: variants}}},
+		{name: "duplicate name", experiments: []Experiment{{Name: "a", Variants: variants}, {Name: "a", Variants: variants}}},
+		{name: "no variants", experiments: []Experiment{{Name: "a"}}},
+		{name: "zero weight", experiments: []Experiment{{Name: "a", Variants: []Variant{{Name: "control", Strategy: "item-cf"}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.experiments)
+			if tt.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidExperiment) {
+				t.Errorf("expected ErrInvalidExperiment, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package experiment
+
+// Experiment splits users between variants, each variant is served by its own recommender strategy
+type Experiment struct {
+	Name     string    `json:"name" example:"als-vs-item-cf"`
+	Enabled  bool      `json:"enabled" example:"true"`
+	Variants []Variant `json:"variants"`
+}
+
+type Variant struct {
+	Name     string `json:"name" example:"treatment"`
+	Strategy string `json:"strategy" example:"als"`
+	// Weight is share of experiment traffic relative to other variants
+	Weight int `json:"weight" example:"50"`
+}
+
+// Assignment is variant user was assigned to
+type Assignment struct {
+	Experiment string `json:"experiment" example:"als-vs-item-cf"`
+	Variant    string `json:"variant" example:"treatment"`
+	Strategy   string `json:"strategy" example:"als"`
+}
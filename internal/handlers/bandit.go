@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/recommend"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type BanditMetricsResponse struct {
+	response.Response
+	Metrics recommend.BanditMetrics `json:"metrics"`
+}
+
+type BanditMetrics interface {
+	Metrics() recommend.BanditMetrics
+}
+
+// NewGetBanditMetrics godoc
+//
+// @Summary get exploration metrics
+// @Description get exploration rate of served slates since start and click statistics bandit samples from,
+// @Description ctr of explored movies against the rest is part of recommendations report
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} BanditMetricsResponse
+// @Router /admin/bandit [get]
+func NewGetBanditMetrics(_ context.Context, log *slog.Logger, bandit BanditMetrics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		metrics := bandit.Metrics()
+		log.Info("got bandit metrics", slog.Float64("exploration_rate", metrics.ExplorationRate))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, BanditMetricsResponse{
+			Response: response.OK(),
+			Metrics:  metrics,
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/recommend"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type CompatibilityResponse struct {
+	response.Response
+	Compatibility recommend.Compatibility `json:"compatibility"`
+}
+
+type CompatibilityComparer interface {
+	Compare(ctx context.Context, userID, otherID string) (recommend.Compatibility, error)
+}
+
+// NewGetCompatibility godoc
+//
+// @Summary get taste compatibility
+// @Description get compatibility percentage of two users from correlation of scores of co-rated movies
+// @Description and overlap of genre affinities, with movies both loved and movies they disagree on most
+// @Tags recommendations
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param otherID path string true "Other user ID"
+// @Success 200 {object} CompatibilityResponse
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/compatibility/{otherID} [get]
+func NewGetCompatibility(ctx context.Context, log *slog.Logger, comparer CompatibilityComparer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		otherID := chi.URLParam(r, "otherID")
+		if id == "" || otherID == "" {
+			log.Info("id is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("id is empty"))
+			return
+		}
+		if id == otherID {
+			log.Info("same user compared", slog.String("user_id", id))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("users must be different"))
+			return
+		}
+		compatibility, err := comparer.Compare(ctx, id, otherID)
+		if err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("user not found")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("user not found"))
+				return
+			}
+			log.Error("failed to compare users", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to compare users"))
+			return
+		}
+		log.Info("compared users", slog.String("user_id", id), slog.String("other_id", otherID),
+			slog.Int("percent", compatibility.Percent))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, CompatibilityResponse{
+			Response:      response.OK(),
+			Compatibility: compatibility,
+		})
+	}
+}
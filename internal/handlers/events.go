@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/events"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/request"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type EventRequest struct {
+	UserID     string    `json:"user_id" validate:"required,uuid" example:"a9aec972-2c52-441a-8f17-79506cd34366"`
+	MovieID    string    `json:"movie_id" validate:"required,uuid" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+	Type       string    `json:"type" validate:"required,oneof=impression click play progress complete" example:"progress"`
+	Progress   int       `json:"progress" validate:"min=0,max=100" example:"40"`
+	Experiment string    `json:"experiment" validate:"max=100" example:"als-vs-item-cf"`
+	Variant    string    `json:"variant" validate:"max=100" example:"treatment"`
+	OccurredAt time.Time `json:"occurred_at" example:"2024-03-17T12:00:00Z"`
+}
+
+type EventsRequest struct {
+	Events []EventRequest `json:"events" validate:"required,min=1,max=1000,dive"`
+}
+
+type EventsResponse struct {
+	response.Response
+	Accepted int `json:"accepted" example:"2"`
+}
+
+type EventQueue interface {
+	Add(events []events.Event) error
+}
+
+// NewPostEvents godoc
+//
+// @Summary post events
+// @Description record batch of up to 1000 user interactions with movies, events are written asynchronously.
+// @Description Impressions of recommendations are logged by server, clicks on them should echo experiment and variant of response
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param input body EventsRequest true "Events"
+// @Success 202 {object} EventsResponse
+// @Failure 400 {object} response.Response
+// @Failure 503 {object} response.Response
+// @Router /events [post]
+func NewPostEvents(_ context.Context, log *slog.Logger, queue EventQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		var req EventsRequest
+		err := render.DecodeJSON(r.Body, &req)
+		if request.BodyEmpty(err, log, w, r) {
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+		batch := make([]events.Event, 0, len(req.Events))
+		for _, e := range req.Events {
+			progress := e.Progress
+			if e.Type == events.TypeComplete {
+				progress = 100
+			}
+			batch = append(batch, events.Event{
+				UserID:     e.UserID,
+				MovieID:    e.MovieID,
+				Type:       e.Type,
+				Progress:   progress,
+				Experiment: e.Experiment,
+				Variant:    e.Variant,
+				OccurredAt: e.OccurredAt,
+			})
+		}
+		if err = queue.Add(batch); err != nil {
+			log.Error("failed to queue events", logging.Err(err))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			render.JSON(w, r, response.Error("events queue is full, retry later"))
+			return
+		}
+		log.Info("events queued", slog.Int("count", len(batch)))
+		w.WriteHeader(http.StatusAccepted)
+		render.JSON(w, r, EventsResponse{
+			Response: response.OK(),
+			Accepted: len(batch),
+		})
+	}
+}
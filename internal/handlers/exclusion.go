@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/exclusion"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/request"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+)
+
+type ExclusionRequest struct {
+	Kind     string `json:"kind" validate:"required,oneof=movie genre director" example:"genre"`
+	TargetID string `json:"target_id" validate:"required,uuid" example:"59457b31-89f8-4ade-b46c-731c61430c3e"`
+}
+
+type ExclusionResponse struct {
+	response.Response
+	Exclusion exclusion.Exclusion `json:"exclusion"`
+}
+
+type ExclusionsResponse struct {
+	response.Response
+	Exclusions []exclusion.Exclusion `json:"exclusions"`
+}
+
+type ExclusionAdder interface {
+	AddExclusion(ctx context.Context, e *exclusion.Exclusion) error
+}
+
+// NewAddExclusion godoc
+//
+// @Summary mark as not interested
+// @Description hide movie, all movies of genre or all movies of director from recommendations and trending lists of user
+// @Tags exclusions
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param input body ExclusionRequest true "Exclusion"
+// @Success 200 {object} ExclusionResponse
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/not-interested [post]
+func NewAddExclusion(ctx context.Context, log *slog.Logger, adder ExclusionAdder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			log.Info("id is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("id is empty"))
+			return
+		}
+		var req ExclusionRequest
+		err := render.DecodeJSON(r.Body, &req)
+		if request.BodyEmpty(err, log, w, r) {
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+		e := exclusion.Exclusion{
+			UserID:   id,
+			Kind:     req.Kind,
+			TargetID: req.TargetID,
+		}
+		if err = adder.AddExclusion(ctx, &e); err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("user or " + req.Kind + " not found")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("user or "+req.Kind+" not found"))
+				return
+			}
+			log.Error("failed to add exclusion", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to add exclusion"))
+			return
+		}
+		log.Info("exclusion added", slog.String("user_id", id), slog.String("kind", e.Kind), slog.String("target_id", e.TargetID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, ExclusionResponse{
+			Response:  response.OK(),
+			Exclusion: e,
+		})
+	}
+}
+
+type ExclusionRemover interface {
+	RemoveExclusion(ctx context.Context, userID, kind, targetID string) error
+}
+
+// NewRemoveExclusion godoc
+//
+// @Summary undo not interested
+// @Description bring excluded movie, genre or director back into recommendations
+// @Tags exclusions
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param kind path string true "movie, genre or director"
+// @Param targetID path string true "Movie, genre or director ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/not-interested/{kind}/{targetID} [delete]
+func NewRemoveExclusion(ctx context.Context, log *slog.Logger, remover ExclusionRemover) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id, kind, targetID := chi.URLParam(r, "id"), chi.URLParam(r, "kind"), chi.URLParam(r, "targetID")
+		if id == "" || targetID == "" {
+			log.Info("id is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("id is empty"))
+			return
+		}
+		if kind != exclusion.KindMovie && kind != exclusion.KindGenre && kind != exclusion.KindDirector {
+			log.Info("invalid kind", slog.String("kind", kind))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("kind must be one of movie, genre, director"))
+			return
+		}
+		if err := remover.RemoveExclusion(ctx, id, kind, targetID); err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("exclusion not found")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("exclusion not found"))
+				return
+			}
+			log.Error("failed to remove exclusion", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to remove exclusion"))
+			return
+		}
+		log.Info("exclusion removed", slog.String("user_id", id), slog.String("kind", kind), slog.String("target_id", targetID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, response.OK())
+	}
+}
+
+// NewGetExclusions godoc
+//
+// @Summary get not interested list
+// @Description get movies, genres and directors user is not interested in, latest first
+// @Tags exclusions
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} ExclusionsResponse
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/not-interested [get]
+func NewGetExclusions(ctx context.Context, log *slog.Logger, source exclusion.Source) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			log.Info("id is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("id is empty"))
+			return
+		}
+		exclusions, err := source.GetExclusions(ctx, id)
+		if err != nil {
+			log.Error("failed to get exclusions", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get exclusions"))
+			return
+		}
+		log.Info("got exclusions", slog.String("user_id", id), slog.Int("count", len(exclusions)))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, ExclusionsResponse{
+			Response:   response.OK(),
+			Exclusions: exclusions,
+		})
+	}
+}
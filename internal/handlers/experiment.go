@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/experiment"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type ExperimentsResponse struct {
+	response.Response
+	Experiments []experiment.Experiment `json:"experiments"`
+}
+
+type ExperimentLister interface {
+	All() []experiment.Experiment
+}
+
+// NewGetExperiments godoc
+//
+// @Summary get experiments
+// @Description get recommender experiments from config with their variants and traffic weights
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} ExperimentsResponse
+// @Router /admin/experiments [get]
+func NewGetExperiments(_ context.Context, log *slog.Logger, lister ExperimentLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		experiments := lister.All()
+		log.Info("got experiments", slog.Int("count", len(experiments)))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, ExperimentsResponse{
+			Response:    response.OK(),
+			Experiments: experiments,
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/recommend"
+	"github.com/danyatalent/movie-recommend/internal/slate"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// exposureTopDirectors is number of directors reported per period
+const exposureTopDirectors = 10
+
+type ExposureReportResponse struct {
+	response.Response
+	Report  slate.ExposureReport      `json:"report"`
+	Current recommend.ExposureMetrics `json:"current"`
+}
+
+type ExposureReporter interface {
+	GetExposureReport(ctx context.Context, since time.Time, bucket string, topDirectors int) (slate.ExposureReport, error)
+}
+
+type ExposureMetrics interface {
+	Metrics() recommend.ExposureMetrics
+}
+
+// NewGetExposureReport godoc
+//
+// @Summary get exposure report
+// @Description get position weighted exposure of served recommendations per period by director country,
+// @Description oscar of director and top directors, with current exposure fairness re-ranking works with
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param days query int false "Report period in days (default 7, max 90)"
+// @Param bucket query string false "Period length: day or week (default day)"
+// @Success 200 {object} ExposureReportResponse
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/exposure [get]
+func NewGetExposureReport(ctx context.Context, log *slog.Logger, reporter ExposureReporter, exposure ExposureMetrics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		days, ok := queryInt(r, "days", defaultReportDays, maxReportDays)
+		if !ok {
+			log.Info("invalid days", slog.String("days", r.URL.Query().Get("days")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("days must be a positive number"))
+			return
+		}
+		bucket := r.URL.Query().Get("bucket")
+		switch bucket {
+		case "":
+			bucket = "day"
+		case "day", "week":
+		default:
+			log.Info("invalid bucket", slog.String("bucket", bucket))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("bucket must be day or week"))
+			return
+		}
+		report, err := reporter.GetExposureReport(ctx, time.Now().AddDate(0, 0, -days), bucket, exposureTopDirectors)
+		if err != nil {
+			log.Error("failed to get exposure report", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get exposure report"))
+			return
+		}
+		log.Info("got exposure report", slog.Int("days", days), slog.String("bucket", bucket))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, ExposureReportResponse{
+			Response: response.OK(),
+			Report:   report,
+			Current:  exposure.Metrics(),
+		})
+	}
+}
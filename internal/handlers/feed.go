@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/recommend"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"math"
+	"net/http"
+)
+
+const (
+	defaultFeedRows  = 10
+	maxFeedRows      = 50
+	defaultFeedItems = 10
+)
+
+type FeedResponse struct {
+	response.Response
+	Feed recommend.Feed `json:"feed"`
+}
+
+type FeedRowResponse struct {
+	response.Response
+	Row recommend.FeedRow `json:"row"`
+}
+
+type FeedBuilder interface {
+	Feed(ctx context.Context, userID string, rows, items recommend.FeedPage) (recommend.Feed, error)
+	Row(ctx context.Context, userID, rowID string, items recommend.FeedPage) (recommend.FeedRow, error)
+}
+
+// NewGetFeed godoc
+//
+// @Summary get home feed
+// @Description get home screen of user as typed rows: continue_watching, recommended, because_you_watched,
+// @Description top_in_genre, favourite_directors and trending. Every movie appears in one row only.
+// @Description Rows are paginated, every row comes with first page of its items, next pages are read by row id
+// @Tags recommendations
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param page query int false "Page of rows (default 1)"
+// @Param rows query int false "Rows per page (default 10, max 50)"
+// @Param items query int false "Items per row (default 10, max 30)"
+// @Success 200 {object} FeedResponse
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/feed [get]
+func NewGetFeed(ctx context.Context, log *slog.Logger, feeds FeedBuilder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			log.Info("id is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("id is empty"))
+			return
+		}
+		rows := recommend.FeedPage{}
+		var ok bool
+		if rows.Number, ok = queryInt(r, "page", 1, math.MaxInt32); !ok {
+			log.Info("invalid page", slog.String("page", r.URL.Query().Get("page")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("page must be a positive number"))
+			return
+		}
+		if rows.Limit, ok = queryInt(r, "rows", defaultFeedRows, maxFeedRows); !ok {
+			log.Info("invalid rows", slog.String("rows", r.URL.Query().Get("rows")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("rows must be a positive number"))
+			return
+		}
+		items := recommend.FeedPage{Number: 1}
+		if items.Limit, ok = queryInt(r, "items", defaultFeedItems, recommend.FeedRowSize); !ok {
+			log.Info("invalid items", slog.String("items", r.URL.Query().Get("items")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("items must be a positive number"))
+			return
+		}
+		feed, err := feeds.Feed(ctx, id, rows, items)
+		if err != nil {
+			log.Error("failed to build feed", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to build feed"))
+			return
+		}
+		log.Info("built feed", slog.String("user_id", id), slog.Int("rows", len(feed.Rows)))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, FeedResponse{
+			Response: response.OK(),
+			Feed:     feed,
+		})
+	}
+}
+
+// NewGetFeedRow godoc
+//
+// @Summary get home feed row
+// @Description get page of items of one row of home feed by row id
+// @Tags recommendations
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param rowID path string true "Row ID"
+// @Param page query int false "Page of items (default 1)"
+// @Param limit query int false "Items per page (default 10, max 30)"
+// @Success 200 {object} FeedRowResponse
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/feed/{rowID} [get]
+func NewGetFeedRow(ctx context.Context, log *slog.Logger, feeds FeedBuilder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		rowID := chi.URLParam(r, "rowID")
+		if id == "" || rowID == "" {
+			log.Info("id is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("id is empty"))
+			return
+		}
+		items := recommend.FeedPage{}
+		var ok bool
+		if items.Number, ok = queryInt(r, "page", 1, math.MaxInt32); !ok {
+			log.Info("invalid page", slog.String("page", r.URL.Query().Get("page")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("page must be a positive number"))
+			return
+		}
+		if items.Limit, ok = queryInt(r, "limit", defaultFeedItems, recommend.FeedRowSize); !ok {
+			log.Info("invalid limit", slog.String("limit", r.URL.Query().Get("limit")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("limit must be a positive number"))
+			return
+		}
+		row, err := feeds.Row(ctx, id, rowID, items)
+		if err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("row not found", slog.String("row_id", rowID))
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("row not found"))
+				return
+			}
+			log.Error("failed to build feed row", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to build feed row"))
+			return
+		}
+		log.Info("built feed row", slog.String("user_id", id), slog.String("row_id", rowID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, FeedRowResponse{
+			Response: response.OK(),
+			Row:      row,
+		})
+	}
+}
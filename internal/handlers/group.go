@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/recommend"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/request"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+)
+
+type GroupRecommendationsRequest struct {
+	UserIDs []string `json:"user_ids" validate:"required,min=2,max=20,unique,dive,uuid" example:"a9aec972-2c52-441a-8f17-79506cd34366,b1c2d3e4-2c52-441a-8f17-79506cd34366"`
+	Limit   int      `json:"limit" validate:"omitempty,min=1,max=100" example:"10"`
+	// Aggregation is average by default
+	Aggregation string `json:"aggregation" validate:"omitempty,oneof=average least_misery most_pleasure" example:"least_misery"`
+	// MaxDuration caps total duration of movies in seconds, 0 means no cap,
+	// movies of unknown duration are skipped under cap
+	MaxDuration int `json:"max_duration" validate:"omitempty,min=1" example:"14400"`
+}
+
+type GroupRecommendationsResponse struct {
+	response.Response
+	recommend.GroupResult
+}
+
+type GroupRecommender interface {
+	Group(ctx context.Context, req recommend.GroupRequest) (recommend.GroupResult, error)
+}
+
+// NewPostGroupRecommendations godoc
+//
+// @Summary get group recommendations
+// @Description get movies to watch together, preferences of members are aggregated by average, least misery or most pleasure.
+// @Description Movies any member rated or isn't interested in are skipped
+// @Tags recommendations
+// @Accept json
+// @Produce json
+// @Param input body GroupRecommendationsRequest true "Group"
+// @Success 200 {object} GroupRecommendationsResponse
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /recommendations/group [post]
+func NewPostGroupRecommendations(ctx context.Context, log *slog.Logger, recommender GroupRecommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		var req GroupRecommendationsRequest
+		err := render.DecodeJSON(r.Body, &req)
+		if request.BodyEmpty(err, log, w, r) {
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+		if req.Limit == 0 {
+			req.Limit = defaultRecommendationsLimit
+		}
+		if req.Aggregation == "" {
+			req.Aggregation = recommend.AggregateAverage
+		}
+		result, err := recommender.Group(ctx, recommend.GroupRequest{
+			UserIDs:     req.UserIDs,
+			Limit:       req.Limit,
+			Aggregation: req.Aggregation,
+			MaxDuration: req.MaxDuration,
+		})
+		if err != nil {
+			log.Error("failed to get group recommendations", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get group recommendations"))
+			return
+		}
+		log.Info("got group recommendations",
+			slog.Int("members", len(req.UserIDs)),
+			slog.Int("count", len(result.Recommendations)),
+			slog.Int("total_duration", result.TotalDuration),
+		)
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, GroupRecommendationsResponse{
+			Response:    response.OK(),
+			GroupResult: result,
+		})
+	}
+}
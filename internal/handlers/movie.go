@@ -21,7 +21,7 @@ type RequestMovie struct {
 	Name        string   `json:"name" validate:"required" example:"Interstellar"`
 	Description string   `json:"description" validate:"required" example:"some text"`
 	Duration    int      `json:"duration" validate:"required" example:"3600"`
-	Rating      float64  `json:"rating" validate:"required" example:"8.1"`
+	Year        int      `json:"year" validate:"omitempty,min=1888,max=2100" example:"2014"`
 	DirectorID  string   `json:"director_id" validate:"required" example:"0ac7ee25-2ebf-4edb-91eb-3d160a0428a8"`
 	GenresID    []string `json:"genres_id" validate:"required" example:"[0ac7ee25-2ebf-4edb-91eb-3d160a0428a8, 59457b31-89f8-4ade-b46c-731c61430c3e]"`
 }
@@ -87,6 +87,11 @@ func NewGetMovie(ctx context.Context, log *slog.Logger, getter MovieGetter) http
 	}
 }
 
+// MovieIndexer takes created and edited movies into recommendations without waiting for refresh
+type MovieIndexer interface {
+	Upsert(m movie.Movie)
+}
+
 type MovieCreator interface {
 	CreateMovie(ctx context.Context, dto *movie.DTO) (string, error)
 	GetGenresByMovie(ctx context.Context, id string) ([]genre.Genre, error)
@@ -104,7 +109,7 @@ type MovieCreator interface {
 // @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /movies [post]
-func NewCreateMovie(ctx context.Context, log *slog.Logger, creator MovieCreator) http.HandlerFunc {
+func NewCreateMovie(ctx context.Context, log *slog.Logger, creator MovieCreator, indexer MovieIndexer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		log = log.With(
@@ -136,7 +141,7 @@ func NewCreateMovie(ctx context.Context, log *slog.Logger, creator MovieCreator)
 			Name:        req.Name,
 			Description: req.Description,
 			Duration:    req.Duration,
-			Rating:      req.Rating,
+			Year:        req.Year,
 			DirectorID:  req.DirectorID,
 			GenresID:    req.GenresID,
 		})
@@ -157,16 +162,107 @@ func NewCreateMovie(ctx context.Context, log *slog.Logger, creator MovieCreator)
 			render.JSON(w, r, response.Error("can't find such genres"))
 			return
 		}
+		m := movie.Movie{
+			ID:          id,
+			Name:        req.Name,
+			Description: req.Description,
+			Duration:    req.Duration,
+			Year:        req.Year,
+			DirectorID:  req.DirectorID,
+			Genres:      genres,
+		}
+		indexer.Upsert(m)
 		log.Info("movie created", slog.String("id", id))
 		w.WriteHeader(http.StatusCreated)
-		MovieResponseOK(w, r, movie.Movie{
+		MovieResponseOK(w, r, m)
+	}
+}
+
+type MovieUpdater interface {
+	UpdateMovie(ctx context.Context, dto *movie.DTO) error
+	GetMovie(ctx context.Context, id string) (movie.Movie, error)
+}
+
+// NewUpdateMovie godoc
+//
+// @Summary update movie
+// @Description replace movie fields and genres by json
+// @Tags movies
+// @Accept json
+// @Produce json
+// @Param id path string true "Movie ID"
+// @Param input body RequestMovie true "Movie"
+// @Success 200 {object} ResponseMovie
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /movies/{id} [put]
+func NewUpdateMovie(ctx context.Context, log *slog.Logger, updater MovieUpdater, indexer MovieIndexer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			log.Info("id is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("id is empty"))
+			return
+		}
+		var req RequestMovie
+		err := render.DecodeJSON(r.Body, &req)
+		if request.BodyEmpty(err, log, w, r) {
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+		err = updater.UpdateMovie(ctx, &movie.DTO{
 			ID:          id,
 			Name:        req.Name,
 			Description: req.Description,
 			Duration:    req.Duration,
-			Rating:      req.Rating,
+			Year:        req.Year,
 			DirectorID:  req.DirectorID,
-			Genres:      genres,
+			GenresID:    req.GenresID,
 		})
+		if err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("entity not found")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("entity not found"))
+				return
+			}
+			log.Error("failed to update movie", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+		// movie is read back to return its rating and genre names
+		m, err := updater.GetMovie(ctx, id)
+		if err != nil {
+			log.Error("failed to get updated movie", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+		indexer.Upsert(m)
+		log.Info("movie updated", slog.String("id", id))
+		w.WriteHeader(http.StatusOK)
+		MovieResponseOK(w, r, m)
 	}
 }
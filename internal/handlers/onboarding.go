@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/onboarding"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/request"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+)
+
+const (
+	defaultMoviesPerGenre = 5
+	maxMoviesPerGenre     = 20
+)
+
+type OnboardingRequest struct {
+	GenreIDs []string `json:"genre_ids" validate:"required_without=MovieIDs,omitempty,max=50,dive,uuid" example:"a9aec972-2c52-441a-8f17-79506cd34366"`
+	MovieIDs []string `json:"movie_ids" validate:"required_without=GenreIDs,omitempty,max=50,dive,uuid" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+}
+
+type QuestionnaireResponse struct {
+	response.Response
+	Genres []onboarding.GenreMovies `json:"genres"`
+}
+
+type PreferencesResponse struct {
+	response.Response
+	Preferences onboarding.Preferences `json:"preferences"`
+}
+
+type QuestionnaireBuilder interface {
+	Questionnaire(perGenre int) []onboarding.GenreMovies
+}
+
+// NewGetOnboarding godoc
+//
+// @Summary get onboarding questionnaire
+// @Description get popular movies of every genre for new user to pick favourites from
+// @Tags onboarding
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param per_genre query int false "Movies per genre (default 5, max 20)"
+// @Success 200 {object} QuestionnaireResponse
+// @Failure 400 {object} response.Response
+// @Router /users/{id}/onboarding [get]
+func NewGetOnboarding(_ context.Context, log *slog.Logger, builder QuestionnaireBuilder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		perGenre, ok := queryInt(r, "per_genre", defaultMoviesPerGenre, maxMoviesPerGenre)
+		if !ok {
+			log.Info("invalid per_genre", slog.String("per_genre", r.URL.Query().Get("per_genre")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("per_genre must be a positive number"))
+			return
+		}
+		genres := builder.Questionnaire(perGenre)
+		log.Info("built questionnaire", slog.Int("genres", len(genres)))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, QuestionnaireResponse{
+			Response: response.OK(),
+			Genres:   genres,
+		})
+	}
+}
+
+type PreferencesSaver interface {
+	SavePreferences(ctx context.Context, p *onboarding.Preferences) error
+}
+
+// NewSaveOnboarding godoc
+//
+// @Summary save onboarding answers
+// @Description save favourite genres and movies picked by user, they seed first recommendations
+// @Tags onboarding
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param input body OnboardingRequest true "Favourites"
+// @Success 200 {object} PreferencesResponse
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/onboarding [post]
+func NewSaveOnboarding(ctx context.Context, log *slog.Logger, saver PreferencesSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if err := validator.New().Var(id, "uuid"); err != nil {
+			log.Info("invalid user id", slog.String("id", id))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("user id must be uuid"))
+			return
+		}
+		var req OnboardingRequest
+		err := render.DecodeJSON(r.Body, &req)
+		if request.BodyEmpty(err, log, w, r) {
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+		prefs := onboarding.Preferences{
+			UserID:   id,
+			GenreIDs: req.GenreIDs,
+			MovieIDs: req.MovieIDs,
+		}
+		if err = saver.SavePreferences(ctx, &prefs); err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("user, genre or movie not found")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("user, genre or movie not found"))
+				return
+			}
+			log.Error("failed to save preferences", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to save preferences"))
+			return
+		}
+		log.Info("preferences saved", slog.String("user_id", id))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, PreferencesResponse{
+			Response:    response.OK(),
+			Preferences: prefs,
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/request"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+)
+
+type RatingRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid" example:"a9aec972-2c52-441a-8f17-79506cd34366"`
+	Score  int    `json:"score" validate:"required,min=1,max=10" example:"8"`
+}
+
+type DeleteRatingRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid" example:"a9aec972-2c52-441a-8f17-79506cd34366"`
+}
+
+type RatingResponse struct {
+	response.Response
+	Rating rating.Rating `json:"rating,omitempty"`
+}
+
+type RatingsResponse struct {
+	response.Response
+	Ratings []rating.Rating `json:"ratings"`
+}
+
+type RatingSetter interface {
+	RateMovie(ctx context.Context, rt *rating.Rating) error
+}
+
+// NewRateMovie godoc
+//
+// @Summary rate movie
+// @Description create or update user's score (1-10) for movie
+// @Tags ratings
+// @Accept json
+// @Produce json
+// @Param id path string true "Movie ID"
+// @Param input body RatingRequest true "Rating"
+// @Success 200 {object} RatingResponse
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /movies/{id}/ratings [put]
+func NewRateMovie(ctx context.Context, log *slog.Logger, setter RatingSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if err := validator.New().Var(id, "uuid"); err != nil {
+			log.Info("invalid movie id", slog.String("id", id))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("movie id must be uuid"))
+			return
+		}
+		var req RatingRequest
+		err := render.DecodeJSON(r.Body, &req)
+		if request.BodyEmpty(err, log, w, r) {
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+		rt := rating.Rating{
+			UserID:  req.UserID,
+			MovieID: id,
+			Score:   req.Score,
+		}
+		if err = setter.RateMovie(ctx, &rt); err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("user or movie not found")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("user or movie not found"))
+				return
+			}
+			log.Error("failed to rate movie", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to rate movie"))
+			return
+		}
+		log.Info("movie rated", slog.String("movie_id", id), slog.String("user_id", req.UserID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, RatingResponse{
+			Response: response.OK(),
+			Rating:   rt,
+		})
+	}
+}
+
+type RatingDeleter interface {
+	DeleteRating(ctx context.Context, userID, movieID string) error
+}
+
+// NewDeleteRating godoc
+//
+// @Summary delete rating
+// @Description delete user's score for movie
+// @Tags ratings
+// @Accept json
+// @Produce json
+// @Param id path string true "Movie ID"
+// @Param input body DeleteRatingRequest true "User"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /movies/{id}/ratings [delete]
+func NewDeleteRating(ctx context.Context, log *slog.Logger, deleter RatingDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if err := validator.New().Var(id, "uuid"); err != nil {
+			log.Info("invalid movie id", slog.String("id", id))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("movie id must be uuid"))
+			return
+		}
+		var req DeleteRatingRequest
+		err := render.DecodeJSON(r.Body, &req)
+		if request.BodyEmpty(err, log, w, r) {
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+		if err = deleter.DeleteRating(ctx, req.UserID, id); err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("entity not found")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("entity not found"))
+				return
+			}
+			log.Error("failed to delete rating", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to delete rating"))
+			return
+		}
+		log.Info("rating deleted", slog.String("movie_id", id), slog.String("user_id", req.UserID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, response.OK())
+	}
+}
+
+type UserRatingsGetter interface {
+	GetRatingsByUser(ctx context.Context, userID string) ([]rating.Rating, error)
+}
+
+// NewGetUserRatings godoc
+//
+// @Summary get user ratings
+// @Description get all scores left by user
+// @Tags ratings
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} RatingsResponse
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/ratings [get]
+func NewGetUserRatings(ctx context.Context, log *slog.Logger, getter UserRatingsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if err := validator.New().Var(id, "uuid"); err != nil {
+			log.Info("invalid user id", slog.String("id", id))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("user id must be uuid"))
+			return
+		}
+		ratings, err := getter.GetRatingsByUser(ctx, id)
+		if err != nil {
+			log.Error("failed to get user ratings", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get user ratings"))
+			return
+		}
+		log.Info("got user ratings", slog.Int("count", len(ratings)))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, RatingsResponse{
+			Response: response.OK(),
+			Ratings:  ratings,
+		})
+	}
+}
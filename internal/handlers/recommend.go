@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/recommend"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultRecommendationsLimit = 10
+	maxRecommendationsLimit     = 100
+)
+
+type RecommendationsResponse struct {
+	response.Response
+	recommend.Result
+}
+
+type Recommender interface {
+	Recommend(ctx context.Context, req recommend.Request) (recommend.Result, error)
+	DefaultDiversity() recommend.Diversity
+}
+
+// NewGetRecommendations godoc
+//
+// @Summary get recommendations
+// @Description get ranked movies for user with reasons of every recommendation, movies rated by user are excluded.
+// @Description Lists are served from precomputed cache, meta.computed_at tells how fresh they are.
+// @Description Filtered lists are computed with filters applied while candidates are generated, so the page stays full
+// @Tags recommendations
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param limit query int false "Number of movies (default 10, max 100)"
+// @Param lambda query number false "Relevance weight of diversity re-ranking in [0, 1], 1 disables it"
+// @Param max_per_director query int false "Max movies of one director, 0 means no cap"
+// @Param max_per_genre query int false "Max movies of one genre, 0 means no cap"
+// @Param genre query string false "Comma separated genre names or ids, movie needs any of them"
+// @Param max_duration query int false "Max duration of movie in seconds"
+// @Param min_rating query number false "Min rating of movie"
+// @Param exclude_director query string false "Comma separated director ids"
+// @Param has_oscar_director query bool false "Only movies of directors with (true) or without (false) oscar"
+// @Param min_year query int false "Min year of release"
+// @Param max_year query int false "Max year of release"
+// @Param debug query bool false "Return components of hybrid strategy scores"
+// @Success 200 {object} RecommendationsResponse
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/recommendations [get]
+func NewGetRecommendations(ctx context.Context, log *slog.Logger, recommender Recommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			log.Info("id is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("id is empty"))
+			return
+		}
+		limit, ok := queryInt(r, "limit", defaultRecommendationsLimit, maxRecommendationsLimit)
+		if !ok {
+			log.Info("invalid limit", slog.String("limit", r.URL.Query().Get("limit")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("limit must be a positive number"))
+			return
+		}
+		diversity, msg := queryDiversity(r, recommender.DefaultDiversity())
+		if msg != "" {
+			log.Info("invalid diversity", slog.String("error", msg))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(msg))
+			return
+		}
+		filter, msg := queryFilter(r)
+		if msg != "" {
+			log.Info("invalid filter", slog.String("error", msg))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(msg))
+			return
+		}
+		var debug bool
+		if raw := r.URL.Query().Get("debug"); raw != "" {
+			var err error
+			if debug, err = strconv.ParseBool(raw); err != nil {
+				log.Info("invalid debug", slog.String("debug", raw))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error("debug must be true or false"))
+				return
+			}
+		}
+		result, err := recommender.Recommend(ctx, recommend.Request{
+			UserID:    id,
+			Limit:     limit,
+			Diversity: diversity,
+			Filter:    filter,
+			Debug:     debug,
+		})
+		if err != nil {
+			log.Error("failed to get recommendations", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get recommendations"))
+			return
+		}
+		log.Info("got recommendations",
+			slog.String("user_id", id),
+			slog.Int("count", len(result.Recommendations)),
+			slog.String("strategy", result.Meta.Strategy),
+			slog.String("experiment", result.Meta.Experiment),
+			slog.String("variant", result.Meta.Variant),
+		)
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, RecommendationsResponse{
+			Response: response.OK(),
+			Result:   result,
+		})
+	}
+}
+
+type ExplanationResponse struct {
+	response.Response
+	Movie        movie.Movie             `json:"movie"`
+	Explanations []recommend.Explanation `json:"explanations"`
+}
+
+type RecommendationExplainer interface {
+	Explain(ctx context.Context, userID, movieID string) (movie.Movie, []recommend.Explanation, error)
+}
+
+// NewGetRecommendationReason godoc
+//
+// @Summary explain recommendation
+// @Description get reasons why movie suits user: liked seed movies, shared genres, same director or popularity
+// @Tags recommendations
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param movieID path string true "Movie ID"
+// @Success 200 {object} ExplanationResponse
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/recommendations/{movieID}/why [get]
+func NewGetRecommendationReason(ctx context.Context, log *slog.Logger, explainer RecommendationExplainer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id, movieID := chi.URLParam(r, "id"), chi.URLParam(r, "movieID")
+		if id == "" || movieID == "" {
+			log.Info("id is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("id is empty"))
+			return
+		}
+		m, explanations, err := explainer.Explain(ctx, id, movieID)
+		if err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("movie not found")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("movie not found"))
+				return
+			}
+			log.Error("failed to explain recommendation", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to explain recommendation"))
+			return
+		}
+		log.Info("explained recommendation", slog.String("user_id", id), slog.String("movie_id", movieID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, ExplanationResponse{
+			Response:     response.OK(),
+			Movie:        m,
+			Explanations: explanations,
+		})
+	}
+}
+
+type SimilarMoviesResponse struct {
+	response.Response
+	Similar []recommend.Similar `json:"similar"`
+}
+
+type SimilarFinder interface {
+	Similar(ctx context.Context, movieID, userID string, limit int) ([]recommend.Similar, error)
+}
+
+// NewGetSimilarMovies godoc
+//
+// @Summary get similar movies
+// @Description get movies sharing genres and director with movie, ranked by content overlap
+// @Tags recommendations
+// @Accept json
+// @Produce json
+// @Param id path string true "Movie ID"
+// @Param user_id query string false "User ID, hides movies user is not interested in"
+// @Param limit query int false "Number of movies (default 10, max 100)"
+// @Success 200 {object} SimilarMoviesResponse
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /movies/{id}/similar [get]
+func NewGetSimilarMovies(ctx context.Context, log *slog.Logger, finder SimilarFinder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			log.Info("id is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("id is empty"))
+			return
+		}
+		limit, ok := queryInt(r, "limit", defaultRecommendationsLimit, maxRecommendationsLimit)
+		if !ok {
+			log.Info("invalid limit", slog.String("limit", r.URL.Query().Get("limit")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("limit must be a positive number"))
+			return
+		}
+		userID, ok := queryUserID(r)
+		if !ok {
+			log.Info("invalid user_id", slog.String("user_id", r.URL.Query().Get("user_id")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("user_id must be uuid"))
+			return
+		}
+		similar, err := finder.Similar(ctx, id, userID, limit)
+		if err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("entity not found")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("entity not found"))
+				return
+			}
+			log.Error("failed to get similar movies", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get similar movies"))
+			return
+		}
+		log.Info("got similar movies", slog.String("movie_id", id), slog.Int("count", len(similar)))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, SimilarMoviesResponse{
+			Response: response.OK(),
+			Similar:  similar,
+		})
+	}
+}
+
+// queryDiversity overrides defaults by lambda, max_per_director and max_per_genre query parameters,
+// returns error message when any of them is invalid
+func queryDiversity(r *http.Request, d recommend.Diversity) (recommend.Diversity, string) {
+	query := r.URL.Query()
+	if raw := query.Get("lambda"); raw != "" {
+		lambda, err := strconv.ParseFloat(raw, 64)
+		if err != nil || lambda < 0 || lambda > 1 {
+			return d, "lambda must be a number between 0 and 1"
+		}
+		d.Lambda = lambda
+	}
+	for name, value := range map[string]*int{
+		"max_per_director": &d.MaxPerDirector,
+		"max_per_genre":    &d.MaxPerGenre,
+	} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return d, name + " must be a non-negative number"
+		}
+		*value = n
+	}
+	return d, ""
+}
+
+// queryFilter reads constraints every recommended movie has to match
+func queryFilter(r *http.Request) (recommend.Filter, string) {
+	query := r.URL.Query()
+	f := recommend.Filter{
+		Genres:           queryList(r, "genre"),
+		ExcludeDirectors: queryList(r, "exclude_director"),
+	}
+	if raw := query.Get("max_duration"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return f, "max_duration must be a positive number"
+		}
+		f.MaxDuration = n
+	}
+	if raw := query.Get("min_rating"); raw != "" {
+		minRating, err := strconv.ParseFloat(raw, 64)
+		if err != nil || minRating < 0 || minRating > 10 {
+			return f, "min_rating must be a number between 0 and 10"
+		}
+		f.MinRating = minRating
+	}
+	if raw := query.Get("has_oscar_director"); raw != "" {
+		hasOscar, err := strconv.ParseBool(raw)
+		if err != nil {
+			return f, "has_oscar_director must be true or false"
+		}
+		f.HasOscarDirector = &hasOscar
+	}
+	for name, value := range map[string]*int{
+		"min_year": &f.MinYear,
+		"max_year": &f.MaxYear,
+	} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return f, name + " must be a positive number"
+		}
+		*value = n
+	}
+	if f.MaxYear > 0 && f.MinYear > f.MaxYear {
+		return f, "min_year must not be greater than max_year"
+	}
+	return f, ""
+}
+
+// queryList splits comma separated query parameter, skipping empty values
+func queryList(r *http.Request, name string) []string {
+	var values []string
+	for _, v := range strings.Split(r.URL.Query().Get(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// queryUserID reads optional user_id query parameter, it must be uuid when present
+func queryUserID(r *http.Request) (string, bool) {
+	userID := r.URL.Query().Get("user_id")
+	if err := validator.New().Var(userID, "omitempty,uuid"); err != nil {
+		return "", false
+	}
+	return userID, true
+}
+
+// queryInt reads positive integer query parameter, returns def when it is absent and caps it by max
+func queryInt(r *http.Request, name string, def, max int) (int, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	if value > max {
+		value = max
+	}
+	return value, true
+}
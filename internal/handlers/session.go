@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/recommend"
+	"github.com/danyatalent/movie-recommend/internal/session"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/request"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+)
+
+// maxTokenLength matches session_views.token column
+const maxTokenLength = 64
+
+type SessionViewRequest struct {
+	MovieID string `json:"movie_id" validate:"required,uuid" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+}
+
+type SessionViewResponse struct {
+	response.Response
+	View session.View `json:"view"`
+}
+
+type SessionRecommendationsResponse struct {
+	response.Response
+	Recommendations []recommend.Recommendation `json:"recommendations"`
+}
+
+type SessionViewAdder interface {
+	AddView(ctx context.Context, v *session.View) error
+}
+
+type SessionRecommender interface {
+	Recommend(ctx context.Context, token string, limit int) ([]recommend.Recommendation, error)
+}
+
+// sessionToken returns token from path, false when it is empty or too long
+func sessionToken(r *http.Request) (string, bool) {
+	token := chi.URLParam(r, "token")
+	return token, token != "" && len(token) <= maxTokenLength
+}
+
+// NewPostSessionView godoc
+//
+// @Summary record session view
+// @Description record movie opened by anonymous visitor, token is generated by client and kept in cookie or header
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param token path string true "Session token, up to 64 characters"
+// @Param input body SessionViewRequest true "View"
+// @Success 200 {object} SessionViewResponse
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /sessions/{token}/views [post]
+func NewPostSessionView(ctx context.Context, log *slog.Logger, adder SessionViewAdder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		token, ok := sessionToken(r)
+		if !ok {
+			log.Info("invalid token")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("token must have 1 to 64 characters"))
+			return
+		}
+		var req SessionViewRequest
+		err := render.DecodeJSON(r.Body, &req)
+		if request.BodyEmpty(err, log, w, r) {
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+		v := session.View{Token: token, MovieID: req.MovieID}
+		if err = adder.AddView(ctx, &v); err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("movie not found")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("movie not found"))
+				return
+			}
+			log.Error("failed to add session view", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to add session view"))
+			return
+		}
+		log.Info("session view added", slog.String("movie_id", v.MovieID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, SessionViewResponse{
+			Response: response.OK(),
+			View:     v,
+		})
+	}
+}
+
+// NewGetSessionRecommendations godoc
+//
+// @Summary get session recommendations
+// @Description get movies anonymous visitor is likely to open next, predicted from latest views of session
+// @Description by transitions between movies mined from events. Sessions without views get movies sessions often move to
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param token path string true "Session token, up to 64 characters"
+// @Param limit query int false "Number of movies (default 10, max 100)"
+// @Success 200 {object} SessionRecommendationsResponse
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /sessions/{token}/recommendations [get]
+func NewGetSessionRecommendations(ctx context.Context, log *slog.Logger, recommender SessionRecommender) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		token, ok := sessionToken(r)
+		if !ok {
+			log.Info("invalid token")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("token must have 1 to 64 characters"))
+			return
+		}
+		limit, ok := queryInt(r, "limit", defaultRecommendationsLimit, maxRecommendationsLimit)
+		if !ok {
+			log.Info("invalid limit", slog.String("limit", r.URL.Query().Get("limit")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("limit must be a positive number"))
+			return
+		}
+		recommendations, err := recommender.Recommend(ctx, token, limit)
+		if err != nil {
+			log.Error("failed to get session recommendations", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get session recommendations"))
+			return
+		}
+		log.Info("got session recommendations", slog.Int("count", len(recommendations)))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, SessionRecommendationsResponse{
+			Response:        response.OK(),
+			Recommendations: recommendations,
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/slate"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/request"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultReportDays = 7
+	maxReportDays     = 90
+)
+
+type FeedbackRequest struct {
+	MovieID string `json:"movie_id" validate:"required,uuid" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+	Action  string `json:"action" validate:"required,oneof=click dismiss" example:"click"`
+}
+
+type FeedbackResponse struct {
+	response.Response
+	Feedback slate.Feedback `json:"feedback"`
+}
+
+type FeedbackSaver interface {
+	SaveFeedback(ctx context.Context, f *slate.Feedback) error
+}
+
+// NewPostRecommendationFeedback godoc
+//
+// @Summary post recommendation feedback
+// @Description record click on or dismissal of movie from served recommendations list
+// @Tags recommendations
+// @Accept json
+// @Produce json
+// @Param id path string true "Recommendation ID"
+// @Param input body FeedbackRequest true "Feedback"
+// @Success 200 {object} FeedbackResponse
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /recommendations/{id}/feedback [post]
+func NewPostRecommendationFeedback(ctx context.Context, log *slog.Logger, saver FeedbackSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if err := validator.New().Var(id, "uuid"); err != nil {
+			log.Info("invalid recommendation id", slog.String("id", id))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("recommendation id must be uuid"))
+			return
+		}
+		var req FeedbackRequest
+		err := render.DecodeJSON(r.Body, &req)
+		if request.BodyEmpty(err, log, w, r) {
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+		f := slate.Feedback{
+			SlateID: id,
+			MovieID: req.MovieID,
+			Action:  req.Action,
+		}
+		if err = saver.SaveFeedback(ctx, &f); err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("movie was not recommended")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("movie is not part of recommendation"))
+				return
+			}
+			log.Error("failed to save feedback", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to save feedback"))
+			return
+		}
+		log.Info("feedback saved", slog.String("recommendation_id", id), slog.String("action", f.Action))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, FeedbackResponse{
+			Response: response.OK(),
+			Feedback: f,
+		})
+	}
+}
+
+type CTRReportResponse struct {
+	response.Response
+	Report slate.Report `json:"report"`
+}
+
+type CTRReporter interface {
+	GetReport(ctx context.Context, since time.Time) (slate.Report, error)
+}
+
+// NewGetCTRReport godoc
+//
+// @Summary get ctr report
+// @Description get click-through rate of served recommendations per strategy, position and genre
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param days query int false "Report period in days (default 7, max 90)"
+// @Success 200 {object} CTRReportResponse
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/recommendations/report [get]
+func NewGetCTRReport(ctx context.Context, log *slog.Logger, reporter CTRReporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		days, ok := queryInt(r, "days", defaultReportDays, maxReportDays)
+		if !ok {
+			log.Info("invalid days", slog.String("days", r.URL.Query().Get("days")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("days must be a positive number"))
+			return
+		}
+		report, err := reporter.GetReport(ctx, time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			log.Error("failed to get report", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get report"))
+			return
+		}
+		log.Info("got ctr report", slog.Int("days", days))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, CTRReportResponse{
+			Response: response.OK(),
+			Report:   report,
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/recommend"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type TasteProfileResponse struct {
+	response.Response
+	Profile recommend.TasteProfile `json:"profile"`
+}
+
+type TasteProfiles interface {
+	Profile(userID string) recommend.TasteProfile
+}
+
+// NewGetTasteProfile godoc
+//
+// @Summary get taste profile
+// @Description get affinity of user to genres and top directors built from ratings, watchlist and events,
+// @Description recent feedback weighs more. Profile is updated shortly after new feedback arrives
+// @Tags recommendations
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} TasteProfileResponse
+// @Failure 400 {object} response.Response
+// @Router /users/{id}/taste-profile [get]
+func NewGetTasteProfile(_ context.Context, log *slog.Logger, profiles TasteProfiles) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			log.Info("id is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("id is empty"))
+			return
+		}
+		profile := profiles.Profile(id)
+		log.Info("got taste profile", slog.String("user_id", id), slog.Int("signals", profile.Signals))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, TasteProfileResponse{
+			Response: response.OK(),
+			Profile:  profile,
+		})
+	}
+}
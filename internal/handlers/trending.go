@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/trending"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+const defaultTrendingWindow = "24h"
+
+type RankedMoviesResponse struct {
+	response.Response
+	Movies []trending.Ranked `json:"movies"`
+}
+
+type TrendingGetter interface {
+	Trending(ctx context.Context, window, genre, userID string, limit int) ([]trending.Ranked, error)
+}
+
+// NewGetTrending godoc
+//
+// @Summary get trending movies
+// @Description get movies ranked by recent ratings and events with exponential time decay
+// @Tags movies
+// @Accept json
+// @Produce json
+// @Param window query string false "Window: 24h, 7d or 30d (default 24h)"
+// @Param genre query string false "Genre ID or name"
+// @Param user_id query string false "User ID, hides movies user is not interested in"
+// @Param limit query int false "Number of movies (default 10, max 100)"
+// @Success 200 {object} RankedMoviesResponse
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /movies/trending [get]
+func NewGetTrending(ctx context.Context, log *slog.Logger, getter TrendingGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		limit, ok := queryInt(r, "limit", defaultRecommendationsLimit, maxRecommendationsLimit)
+		if !ok {
+			log.Info("invalid limit", slog.String("limit", r.URL.Query().Get("limit")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("limit must be a positive number"))
+			return
+		}
+		window := r.URL.Query().Get("window")
+		if window == "" {
+			window = defaultTrendingWindow
+		}
+		userID, ok := queryUserID(r)
+		if !ok {
+			log.Info("invalid user_id", slog.String("user_id", r.URL.Query().Get("user_id")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("user_id must be uuid"))
+			return
+		}
+		movies, err := getter.Trending(ctx, window, r.URL.Query().Get("genre"), userID, limit)
+		if err != nil {
+			if errors.Is(err, trending.ErrUnknownWindow) {
+				log.Info("unknown window", slog.String("window", window))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error("window must be one of 24h, 7d, 30d"))
+				return
+			}
+			log.Error("failed to get trending movies", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get trending movies"))
+			return
+		}
+		log.Info("got trending movies", slog.String("window", window), slog.Int("count", len(movies)))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, RankedMoviesResponse{
+			Response: response.OK(),
+			Movies:   movies,
+		})
+	}
+}
+
+type TopGetter interface {
+	Top(ctx context.Context, genre, userID string, limit int) ([]trending.Ranked, error)
+}
+
+// NewGetTop godoc
+//
+// @Summary get top movies
+// @Description get movies ranked by bayesian weighted rating, movies with few votes are pulled to the mean
+// @Tags movies
+// @Accept json
+// @Produce json
+// @Param genre query string false "Genre ID or name"
+// @Param user_id query string false "User ID, hides movies user is not interested in"
+// @Param limit query int false "Number of movies (default 10, max 100)"
+// @Success 200 {object} RankedMoviesResponse
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /movies/top [get]
+func NewGetTop(ctx context.Context, log *slog.Logger, getter TopGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		limit, ok := queryInt(r, "limit", defaultRecommendationsLimit, maxRecommendationsLimit)
+		if !ok {
+			log.Info("invalid limit", slog.String("limit", r.URL.Query().Get("limit")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("limit must be a positive number"))
+			return
+		}
+		userID, ok := queryUserID(r)
+		if !ok {
+			log.Info("invalid user_id", slog.String("user_id", r.URL.Query().Get("user_id")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("user_id must be uuid"))
+			return
+		}
+		movies, err := getter.Top(ctx, r.URL.Query().Get("genre"), userID, limit)
+		if err != nil {
+			log.Error("failed to get top movies", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get top movies"))
+			return
+		}
+		log.Info("got top movies", slog.Int("count", len(movies)))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, RankedMoviesResponse{
+			Response: response.OK(),
+			Movies:   movies,
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/watchlist"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/danyatalent/movie-recommend/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"math"
+	"net/http"
+)
+
+const defaultWatchlistLimit = 20
+
+type WatchlistResponse struct {
+	response.Response
+	Items []watchlist.Item `json:"items"`
+	Page  int              `json:"page" example:"1"`
+	Limit int              `json:"limit" example:"20"`
+}
+
+type WatchlistAdder interface {
+	AddToWatchlist(ctx context.Context, userID, movieID string) error
+}
+
+// NewAddToWatchlist godoc
+//
+// @Summary add to watchlist
+// @Description save movie for later, adding saved movie again is not an error
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param movieID path string true "Movie ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/watchlist/{movieID} [post]
+func NewAddToWatchlist(ctx context.Context, log *slog.Logger, adder WatchlistAdder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id, movieID := chi.URLParam(r, "id"), chi.URLParam(r, "movieID")
+		if err := validator.New().Var(id, "uuid"); err != nil {
+			log.Info("invalid user id", slog.String("id", id))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("user id must be uuid"))
+			return
+		}
+		if err := validator.New().Var(movieID, "uuid"); err != nil {
+			log.Info("invalid movie id", slog.String("movie_id", movieID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("movie id must be uuid"))
+			return
+		}
+		if err := adder.AddToWatchlist(ctx, id, movieID); err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("user or movie not found")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("user or movie not found"))
+				return
+			}
+			log.Error("failed to add to watchlist", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to add to watchlist"))
+			return
+		}
+		log.Info("added to watchlist", slog.String("user_id", id), slog.String("movie_id", movieID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, response.OK())
+	}
+}
+
+type WatchlistRemover interface {
+	RemoveFromWatchlist(ctx context.Context, userID, movieID string) error
+}
+
+// NewRemoveFromWatchlist godoc
+//
+// @Summary remove from watchlist
+// @Description remove saved movie from watchlist
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param movieID path string true "Movie ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/watchlist/{movieID} [delete]
+func NewRemoveFromWatchlist(ctx context.Context, log *slog.Logger, remover WatchlistRemover) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id, movieID := chi.URLParam(r, "id"), chi.URLParam(r, "movieID")
+		if err := validator.New().Var(id, "uuid"); err != nil {
+			log.Info("invalid user id", slog.String("id", id))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("user id must be uuid"))
+			return
+		}
+		if err := validator.New().Var(movieID, "uuid"); err != nil {
+			log.Info("invalid movie id", slog.String("movie_id", movieID))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("movie id must be uuid"))
+			return
+		}
+		if err := remover.RemoveFromWatchlist(ctx, id, movieID); err != nil {
+			if errors.Is(err, apperror.ErrEntityNotFound) {
+				log.Info("entity not found")
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("entity not found"))
+				return
+			}
+			log.Error("failed to remove from watchlist", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to remove from watchlist"))
+			return
+		}
+		log.Info("removed from watchlist", slog.String("user_id", id), slog.String("movie_id", movieID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, response.OK())
+	}
+}
+
+type WatchlistGetter interface {
+	GetWatchlist(ctx context.Context, userID string, page watchlist.Page) ([]watchlist.Item, error)
+}
+
+// NewGetWatchlist godoc
+//
+// @Summary get watchlist
+// @Description get movies saved by user with genres, paginated
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param sort query string false "Sort by added_at, rating or duration (default added_at)"
+// @Param order query string false "asc or desc (default desc)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param page query int false "Page number (default 1)"
+// @Success 200 {object} WatchlistResponse
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /users/{id}/watchlist [get]
+func NewGetWatchlist(ctx context.Context, log *slog.Logger, getter WatchlistGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log := log.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+		id := chi.URLParam(r, "id")
+		if err := validator.New().Var(id, "uuid"); err != nil {
+			log.Info("invalid user id", slog.String("id", id))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("user id must be uuid"))
+			return
+		}
+		page := watchlist.Page{Sort: watchlist.SortAddedAt, Descending: true}
+		if s := r.URL.Query().Get("sort"); s != "" {
+			if s != watchlist.SortAddedAt && s != watchlist.SortRating && s != watchlist.SortDuration {
+				log.Info("invalid sort", slog.String("sort", s))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error("sort must be one of added_at, rating, duration"))
+				return
+			}
+			page.Sort = s
+		}
+		switch r.URL.Query().Get("order") {
+		case "", "desc":
+		case "asc":
+			page.Descending = false
+		default:
+			log.Info("invalid order", slog.String("order", r.URL.Query().Get("order")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("order must be asc or desc"))
+			return
+		}
+		var ok bool
+		if page.Limit, ok = queryInt(r, "limit", defaultWatchlistLimit, maxRecommendationsLimit); !ok {
+			log.Info("invalid limit", slog.String("limit", r.URL.Query().Get("limit")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("limit must be a positive number"))
+			return
+		}
+		if page.Number, ok = queryInt(r, "page", 1, math.MaxInt32); !ok {
+			log.Info("invalid page", slog.String("page", r.URL.Query().Get("page")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("page must be a positive number"))
+			return
+		}
+		items, err := getter.GetWatchlist(ctx, id, page)
+		if err != nil {
+			log.Error("failed to get watchlist", logging.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get watchlist"))
+			return
+		}
+		log.Info("got watchlist", slog.String("user_id", id), slog.Int("count", len(items)))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, WatchlistResponse{
+			Response: response.OK(),
+			Items:    items,
+			Page:     page.Number,
+			Limit:    page.Limit,
+		})
+	}
+}
@@ -28,7 +28,6 @@ func TestRepository_Create(t *testing.T) {
 		Name:        "Dune",
 		Description: "some text",
 		Duration:    19200,
-		Rating:      8.0,
 		DirectorID:  "82a276e5-b852-48d6-a023-7b119faa76e6",
 		GenresID:    []string{"59457b31-89f8-4ade-b46c-731c61430c3e", "23bb4312-7fc3-4238-aaca-0d27b0a11fb3"},
 	}
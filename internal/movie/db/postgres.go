@@ -21,12 +21,12 @@ type Repository struct {
 }
 
 func (r *Repository) CreateMovie(ctx context.Context, movie *movie.DTO) (string, error) {
-	queryMovies := "insert into movies(name, description, duration, rating, director_id) values ($1, $2, $3, $4, $5) returning id"
+	queryMovies := "insert into movies(name, description, duration, year, director_id) values ($1, $2, $3, nullif($4, 0), $5) returning id"
 	r.logger.Info("creating movie", slog.String("query", queryMovies))
 	errCh := make(chan error, len(movie.GenresID))
 
 	if err := r.client.QueryRow(ctx, queryMovies, movie.Name, movie.Description,
-		movie.Duration, movie.Rating, movie.DirectorID).Scan(&movie.ID); err != nil {
+		movie.Duration, movie.Year, movie.DirectorID).Scan(&movie.ID); err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Code: %s, SQLState: %s",
@@ -68,11 +68,56 @@ func (r *Repository) CreateMovie(ctx context.Context, movie *movie.DTO) (string,
 	return movie.ID, nil
 }
 
+// UpdateMovie replaces all fields and genres of movie in one transaction
+func (r *Repository) UpdateMovie(ctx context.Context, movie *movie.DTO) error {
+	q := "update movies set name=$1, description=$2, duration=$3, year=nullif($4, 0), director_id=$5 where id=$6"
+	r.logger.Info("updating movie", slog.String("query", q))
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, q, movie.Name, movie.Description, movie.Duration, movie.Year, movie.DirectorID, movie.ID)
+	if err != nil {
+		return r.wrapError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return apperror.ErrEntityNotFound
+	}
+	if _, err = tx.Exec(ctx, "delete from movies_genres where movie_id=$1", movie.ID); err != nil {
+		return fmt.Errorf("can't delete genres of movie: %w", err)
+	}
+	for _, genreID := range movie.GenresID {
+		q := "insert into movies_genres(movie_id, genre_id) values ($1, $2) on conflict do nothing"
+		if _, err = tx.Exec(ctx, q, movie.ID, genreID); err != nil {
+			return r.wrapError(err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// wrapError reports missing director or genre as missing entity
+func (r *Repository) wrapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if pgErr.SQLState() == apperror.ErrForeignKeyCode {
+			return apperror.ErrEntityNotFound
+		}
+		newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Code: %s, SQLState: %s",
+			pgErr.Message, pgErr.Detail, pgErr.Code, pgErr.SQLState()))
+		r.logger.Error("error due query", logging.Err(newErr))
+		return newErr
+	}
+	return err
+}
+
 func (r *Repository) GetMovie(ctx context.Context, id string) (movie.Movie, error) {
-	queryMovies := "select id, name, description, duration, rating, director_id from movies where id=$1"
+	queryMovies := "select id, name, description, duration, rating, coalesce(year, 0), director_id from movies where id=$1"
 	r.logger.Info("getting movie by id")
 	var m movie.Movie
-	err := r.client.QueryRow(ctx, queryMovies, id).Scan(&m.ID, &m.Name, &m.Description, &m.Duration, &m.Rating, &m.DirectorID)
+	err := r.client.QueryRow(ctx, queryMovies, id).Scan(&m.ID, &m.Name, &m.Description, &m.Duration, &m.Rating,
+		&m.Year, &m.DirectorID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return movie.Movie{}, apperror.ErrEntityNotFound
@@ -121,6 +166,47 @@ func (r *Repository) GetGenresByMovie(ctx context.Context, id string) ([]genre.G
 	return genres, nil
 }
 
+// GetAllMovies returns every movie with its genres, used to build in-memory catalog
+func (r *Repository) GetAllMovies(ctx context.Context) ([]movie.Movie, error) {
+	q := `select m.id, m.name, coalesce(m.description, ''), coalesce(m.duration, 0), m.rating, coalesce(m.year, 0),
+				 m.director_id,
+				 coalesce(array_agg(g.id::text) filter (where g.id is not null), '{}'),
+				 coalesce(array_agg(g.name) filter (where g.id is not null), '{}')
+		  from movies m
+		  left join movies_genres mg on mg.movie_id = m.id
+		  left join genres g on g.id = mg.genre_id
+		  group by m.id`
+	r.logger.Debug("getting all movies", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := make([]movie.Movie, 0)
+	for rows.Next() {
+		var (
+			m          movie.Movie
+			genreIDs   []string
+			genreNames []string
+		)
+		err = rows.Scan(&m.ID, &m.Name, &m.Description, &m.Duration, &m.Rating, &m.Year, &m.DirectorID,
+			&genreIDs, &genreNames)
+		if err != nil {
+			return nil, err
+		}
+		m.Genres = make([]genre.Genre, 0, len(genreIDs))
+		for i := range genreIDs {
+			m.Genres = append(m.Genres, genre.Genre{ID: genreIDs[i], Name: genreNames[i]})
+		}
+		movies = append(movies, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
 func NewRepository(client postgresql.Client, logger *slog.Logger) *Repository {
 	return &Repository{
 		client: client,
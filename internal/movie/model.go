@@ -9,17 +9,19 @@ type DTO struct {
 	Name        string   `json:"name"`
 	Description string   `json:"description"`
 	Duration    int      `json:"duration"`
-	Rating      float64  `json:"rating"`
+	Year        int      `json:"year"`
 	DirectorID  string   `json:"director_id"`
 	GenresID    []string `json:"genres_id"`
 }
 
 type Movie struct {
-	ID          string        `json:"id" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
-	Name        string        `json:"name" example:"Dune"`
-	Description string        `json:"description" example:"some text"`
-	Duration    int           `json:"duration" example:"19200"`
-	Rating      float64       `json:"rating" example:"7.5"`
-	DirectorID  string        `json:"director_id" example:"0ac7ee25-2ebf-4edb-91eb-3d160a0428a8"`
-	Genres      []genre.Genre `json:"genres"`
+	ID          string  `json:"id" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+	Name        string  `json:"name" example:"Dune"`
+	Description string  `json:"description" example:"some text"`
+	Duration    int     `json:"duration" example:"19200"`
+	Rating      float64 `json:"rating" example:"7.5"`
+	// Year of release, 0 when unknown
+	Year       int           `json:"year" example:"2021"`
+	DirectorID string        `json:"director_id" example:"0ac7ee25-2ebf-4edb-91eb-3d160a0428a8"`
+	Genres     []genre.Genre `json:"genres"`
 }
@@ -0,0 +1,104 @@
+package onboarding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/onboarding"
+	"github.com/danyatalent/movie-recommend/pkg/client/postgresql"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/jackc/pgx/v5/pgconn"
+	"log/slog"
+)
+
+type Repository struct {
+	client postgresql.Client
+	logger *slog.Logger
+}
+
+func NewRepository(client postgresql.Client, logger *slog.Logger) *Repository {
+	return &Repository{
+		client: client,
+		logger: logger,
+	}
+}
+
+// SavePreferences replaces previously picked genres and movies of user
+func (r *Repository) SavePreferences(ctx context.Context, p *onboarding.Preferences) error {
+	r.logger.Info("saving preferences", slog.String("user_id", p.UserID))
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "delete from user_genre_preferences where user_id=$1", p.UserID); err != nil {
+		return fmt.Errorf("can't delete genre preferences: %w", err)
+	}
+	if _, err = tx.Exec(ctx, "delete from user_movie_preferences where user_id=$1", p.UserID); err != nil {
+		return fmt.Errorf("can't delete movie preferences: %w", err)
+	}
+	for _, genreID := range p.GenreIDs {
+		q := "insert into user_genre_preferences(user_id, genre_id) values ($1, $2) on conflict do nothing"
+		if _, err = tx.Exec(ctx, q, p.UserID, genreID); err != nil {
+			return r.wrapError(err)
+		}
+	}
+	for _, movieID := range p.MovieIDs {
+		q := "insert into user_movie_preferences(user_id, movie_id) values ($1, $2) on conflict do nothing"
+		if _, err = tx.Exec(ctx, q, p.UserID, movieID); err != nil {
+			return r.wrapError(err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) wrapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if pgErr.SQLState() == apperror.ErrForeignKeyCode {
+			return apperror.ErrEntityNotFound
+		}
+		newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Code: %s, SQLState: %s",
+			pgErr.Message, pgErr.Detail, pgErr.Code, pgErr.SQLState()))
+		r.logger.Error("error due query", logging.Err(newErr))
+		return newErr
+	}
+	return err
+}
+
+func (r *Repository) GetPreferences(ctx context.Context, userID string) (onboarding.Preferences, error) {
+	r.logger.Debug("getting preferences", slog.String("user_id", userID))
+	p := onboarding.Preferences{UserID: userID}
+	var err error
+	p.GenreIDs, err = r.queryIDs(ctx, "select genre_id::text from user_genre_preferences where user_id=$1", userID)
+	if err != nil {
+		return onboarding.Preferences{}, err
+	}
+	p.MovieIDs, err = r.queryIDs(ctx, "select movie_id::text from user_movie_preferences where user_id=$1", userID)
+	if err != nil {
+		return onboarding.Preferences{}, err
+	}
+	return p, nil
+}
+
+func (r *Repository) queryIDs(ctx context.Context, q, userID string) ([]string, error) {
+	rows, err := r.client.Query(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,19 @@
+package onboarding
+
+import (
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+)
+
+// Preferences are favourite genres and movies picked by new user
+type Preferences struct {
+	UserID   string   `json:"user_id" example:"a9aec972-2c52-441a-8f17-79506cd34366"`
+	GenreIDs []string `json:"genre_ids" example:"a9aec972-2c52-441a-8f17-79506cd34366"`
+	MovieIDs []string `json:"movie_ids" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+}
+
+// GenreMovies is one question of questionnaire: popular movies of genre to pick from
+type GenreMovies struct {
+	Genre  genre.Genre   `json:"genre"`
+	Movies []movie.Movie `json:"movies"`
+}
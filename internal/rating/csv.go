@@ -0,0 +1,52 @@
+package rating
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{"user_id", "movie_id", "score", "rated_at"}
+
+// ReadCSV reads ratings written by WriteCSV, rated_at is RFC3339
+func ReadCSV(r io.Reader) ([]Rating, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(csvHeader)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	ratings := make([]Rating, 0, len(records))
+	for i, record := range records {
+		if i == 0 && record[0] == csvHeader[0] {
+			continue
+		}
+		score, err := strconv.Atoi(record[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid score: %w", i+1, err)
+		}
+		ratedAt, err := time.Parse(time.RFC3339, record[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rated_at: %w", i+1, err)
+		}
+		ratings = append(ratings, Rating{UserID: record[0], MovieID: record[1], Score: score, RatedAt: ratedAt})
+	}
+	return ratings, nil
+}
+
+func WriteCSV(w io.Writer, ratings []Rating) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, rt := range ratings {
+		record := []string{rt.UserID, rt.MovieID, strconv.Itoa(rt.Score), rt.RatedAt.Format(time.RFC3339)}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
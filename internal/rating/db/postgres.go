@@ -0,0 +1,124 @@
+package rating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"github.com/danyatalent/movie-recommend/pkg/client/postgresql"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"log/slog"
+)
+
+// queryRecomputeRating keeps movies.rating equal to the average of user scores
+const queryRecomputeRating = `update movies
+							  set rating = coalesce((select round(avg(score), 1) from ratings where movie_id = $1), 0)
+							  where id = $1`
+
+type Repository struct {
+	client postgresql.Client
+	logger *slog.Logger
+}
+
+func NewRepository(client postgresql.Client, logger *slog.Logger) *Repository {
+	return &Repository{
+		client: client,
+		logger: logger,
+	}
+}
+
+// RateMovie creates or replaces user's score for movie and recomputes movie rating
+func (r *Repository) RateMovie(ctx context.Context, rt *rating.Rating) error {
+	q := `insert into ratings(user_id, movie_id, score) values ($1, $2, $3)
+		  on conflict (user_id, movie_id) do update set score = excluded.score, rated_at = now()
+		  returning rated_at`
+	r.logger.Info("rating movie", slog.String("query", q))
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = tx.QueryRow(ctx, q, rt.UserID, rt.MovieID, rt.Score).Scan(&rt.RatedAt); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.SQLState() == apperror.ErrForeignKeyCode {
+				return apperror.ErrEntityNotFound
+			}
+			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Code: %s, SQLState: %s",
+				pgErr.Message, pgErr.Detail, pgErr.Code, pgErr.SQLState()))
+			r.logger.Error("error due query", logging.Err(newErr))
+			return newErr
+		}
+		return err
+	}
+	if _, err = tx.Exec(ctx, queryRecomputeRating, rt.MovieID); err != nil {
+		r.logger.Error("error due recomputing movie rating", logging.Err(err))
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteRating removes user's score for movie and recomputes movie rating
+func (r *Repository) DeleteRating(ctx context.Context, userID, movieID string) error {
+	q := "delete from ratings where user_id=$1 and movie_id=$2"
+	r.logger.Info("deleting rating", slog.String("query", q))
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, q, userID, movieID)
+	if err != nil {
+		return fmt.Errorf("can't delete rating: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return apperror.ErrEntityNotFound
+	}
+	if _, err = tx.Exec(ctx, queryRecomputeRating, movieID); err != nil {
+		r.logger.Error("error due recomputing movie rating", logging.Err(err))
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) GetRatingsByUser(ctx context.Context, userID string) ([]rating.Rating, error) {
+	q := "select user_id, movie_id, score, rated_at from ratings where user_id=$1 order by rated_at desc"
+	r.logger.Debug("getting ratings by user", slog.String("user_id", userID))
+	rows, err := r.client.Query(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	return collectRatings(rows)
+}
+
+// GetAllRatings returns every rating, used to train recommenders
+func (r *Repository) GetAllRatings(ctx context.Context) ([]rating.Rating, error) {
+	q := "select user_id, movie_id, score, rated_at from ratings"
+	r.logger.Debug("getting all ratings", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return collectRatings(rows)
+}
+
+func collectRatings(rows pgx.Rows) ([]rating.Rating, error) {
+	defer rows.Close()
+	ratings := make([]rating.Rating, 0)
+	for rows.Next() {
+		var rt rating.Rating
+		if err := rows.Scan(&rt.UserID, &rt.MovieID, &rt.Score, &rt.RatedAt); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, rt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ratings, nil
+}
@@ -0,0 +1,15 @@
+package rating
+
+import "time"
+
+const (
+	MinScore = 1
+	MaxScore = 10
+)
+
+type Rating struct {
+	UserID  string    `json:"user_id" example:"a9aec972-2c52-441a-8f17-79506cd34366"`
+	MovieID string    `json:"movie_id" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+	Score   int       `json:"score" example:"8"`
+	RatedAt time.Time `json:"rated_at" example:"2024-03-17T12:00:00Z"`
+}
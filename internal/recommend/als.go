@@ -0,0 +1,198 @@
+package recommend
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+type ALSParams struct {
+	Factors    int
+	Iterations int
+	Lambda     float64
+	Seed       int64
+}
+
+type TrainMetrics struct {
+	TrainRMSE float64 `json:"train_rmse"`
+	// TestRMSE is 0 when model was trained without holdout
+	TestRMSE float64 `json:"test_rmse"`
+	Ratings  int     `json:"ratings"`
+	Users    int     `json:"users"`
+	Items    int     `json:"items"`
+}
+
+// FactorModel is a trained matrix factorization, score is GlobalMean + user·item
+type FactorModel struct {
+	Version    string
+	Params     ALSParams
+	GlobalMean float64
+	Users      map[string][]float64
+	Items      map[string][]float64
+	Metrics    TrainMetrics
+	CreatedAt  time.Time
+}
+
+// TrainALS factorizes dataset with alternating least squares and weighted lambda regularization
+func TrainALS(ds *Dataset, params ALSParams) *FactorModel {
+	rnd := rand.New(rand.NewSource(params.Seed))
+	model := &FactorModel{
+		Params: params,
+		Users:  make(map[string][]float64),
+		Items:  make(map[string][]float64),
+	}
+
+	var sum float64
+	var count int
+	for _, userID := range ds.Users() {
+		for _, score := range ds.UserRatings(userID) {
+			sum += score
+			count++
+		}
+	}
+	if count == 0 {
+		return model
+	}
+	model.GlobalMean = sum / float64(count)
+
+	for _, movieID := range ds.Items() {
+		v := make([]float64, params.Factors)
+		for k := range v {
+			v[k] = rnd.NormFloat64() * 0.1
+		}
+		model.Items[movieID] = v
+	}
+	for _, userID := range ds.Users() {
+		model.Users[userID] = make([]float64, params.Factors)
+	}
+
+	for it := 0; it < params.Iterations; it++ {
+		for userID, vec := range model.Users {
+			copy(vec, solveFactors(ds.UserRatings(userID), model.Items, model.GlobalMean, params))
+		}
+		for movieID, vec := range model.Items {
+			copy(vec, solveFactors(ds.ItemRatings(movieID), model.Users, model.GlobalMean, params))
+		}
+	}
+
+	model.Metrics = TrainMetrics{
+		TrainRMSE: model.RMSE(ds),
+		Ratings:   count,
+		Users:     len(model.Users),
+		Items:     len(model.Items),
+	}
+	return model
+}
+
+// FoldIn computes factors for user unknown to model from current ratings keeping items fixed
+func (m *FactorModel) FoldIn(ratings map[string]float64) []float64 {
+	return solveFactors(ratings, m.Items, m.GlobalMean, m.Params)
+}
+
+func (m *FactorModel) Predict(userVec []float64, movieID string) (float64, bool) {
+	itemVec, ok := m.Items[movieID]
+	if !ok || userVec == nil {
+		return 0, false
+	}
+	return m.GlobalMean + dot(userVec, itemVec), true
+}
+
+// RMSE of model on dataset, ratings of unknown users or movies are skipped
+func (m *FactorModel) RMSE(ds *Dataset) float64 {
+	var sum float64
+	var count int
+	for _, userID := range ds.Users() {
+		userVec, ok := m.Users[userID]
+		if !ok {
+			continue
+		}
+		for movieID, score := range ds.UserRatings(userID) {
+			p, ok := m.Predict(userVec, movieID)
+			if !ok {
+				continue
+			}
+			sum += (p - score) * (p - score)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return math.Sqrt(sum / float64(count))
+}
+
+// solveFactors solves (FᵀF + λnI)x = Fᵀ(r - μ) for one row of ratings against fixed factors
+func solveFactors(ratings map[string]float64, fixed map[string][]float64, mean float64, params ALSParams) []float64 {
+	k := params.Factors
+	a := make([][]float64, k)
+	for i := range a {
+		a[i] = make([]float64, k)
+	}
+	b := make([]float64, k)
+	var n int
+	for id, score := range ratings {
+		f, ok := fixed[id]
+		if !ok {
+			continue
+		}
+		n++
+		for i := 0; i < k; i++ {
+			b[i] += f[i] * (score - mean)
+			for j := 0; j < k; j++ {
+				a[i][j] += f[i] * f[j]
+			}
+		}
+	}
+	if n == 0 {
+		return make([]float64, k)
+	}
+	for i := 0; i < k; i++ {
+		a[i][i] += params.Lambda * float64(n)
+	}
+	return solve(a, b)
+}
+
+// solve is Gaussian elimination with partial pivoting, a and b are modified
+func solve(a [][]float64, b []float64) []float64 {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		if a[col][col] == 0 {
+			continue
+		}
+		for row := col + 1; row < n; row++ {
+			f := a[row][col] / a[col][col]
+			for j := col; j < n; j++ {
+				a[row][j] -= f * a[col][j]
+			}
+			b[row] -= f * b[col]
+		}
+	}
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		if a[row][row] == 0 {
+			continue
+		}
+		s := b[row]
+		for j := row + 1; j < n; j++ {
+			s -= a[row][j] * x[j]
+		}
+		x[row] = s / a[row][row]
+	}
+	return x
+}
+
+func dot(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
@@ -0,0 +1,34 @@
+package recommend
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSolve(t *testing.T) {
+	a := [][]float64{{4, 1}, {1, 3}}
+	b := []float64{1, 2}
+	x := solve(a, b)
+	if math.Abs(x[0]-1.0/11) > 1e-9 || math.Abs(x[1]-7.0/11) > 1e-9 {
+		t.Errorf("wrong solution: %v", x)
+	}
+}
+
+func TestTrainALS(t *testing.T) {
+	ds := NewDataset(testRatings())
+	model := TrainALS(ds, ALSParams{Factors: 3, Iterations: 20, Lambda: 0.01, Seed: 1})
+	if model.Metrics.Ratings != len(testRatings()) {
+		t.Errorf("wrong number of ratings: %d", model.Metrics.Ratings)
+	}
+	if model.Metrics.TrainRMSE > 1.0 {
+		t.Errorf("train rmse is too high: %f", model.Metrics.TrainRMSE)
+	}
+
+	// folded in user who liked dune must prefer interstellar over notebook
+	userVec := model.FoldIn(map[string]float64{"dune": 10, "titanic": 2})
+	interstellar, _ := model.Predict(userVec, "interstellar")
+	notebook, _ := model.Predict(userVec, "notebook")
+	if interstellar <= notebook {
+		t.Errorf("interstellar must be predicted higher than notebook: %f <= %f", interstellar, notebook)
+	}
+}
@@ -0,0 +1,324 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/slate"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReasonExploration explains movie shown to learn how users react to it
+const ReasonExploration = "exploration"
+
+type MovieStatsSource interface {
+	GetMovieStats(ctx context.Context) ([]slate.MovieStats, error)
+}
+
+// Exploration configures bandit, Slots is number of positions of every slate reserved for exploration
+type Exploration struct {
+	Slots int
+	// PriorClicks and PriorImpressions form Beta prior of click probability of movie never served
+	PriorClicks      float64
+	PriorImpressions float64
+	// NewMovieBoost multiplies prior clicks of movies created within NewMovieWindow
+	NewMovieBoost  float64
+	NewMovieWindow time.Duration
+	// PoolSize caps movies sampled for every slate, new and least served movies are kept, 0 means no cap
+	PoolSize int
+}
+
+// Arm is click statistics of movie as seen by bandit
+type Arm struct {
+	MovieID     string  `json:"movie_id" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+	Impressions int     `json:"impressions" example:"40"`
+	Clicks      int     `json:"clicks" example:"3"`
+	New         bool    `json:"new" example:"true"`
+	Mean        float64 `json:"mean" example:"0.08"`
+}
+
+type BanditMetrics struct {
+	Slots int `json:"slots" example:"2"`
+	// Slates is number of served slates, Explored is how many of them had exploration slots
+	Slates   int64 `json:"slates" example:"1000"`
+	Explored int64 `json:"explored" example:"980"`
+	// Items is number of served movies, ExplorationItems is how many of them were explored
+	Items            int64   `json:"items" example:"20000"`
+	ExplorationItems int64   `json:"exploration_items" example:"1960"`
+	ExplorationRate  float64 `json:"exploration_rate" example:"0.098"`
+	Arms             int     `json:"arms" example:"5000"`
+	NewArms          int     `json:"new_arms" example:"12"`
+	// Top are arms with highest posterior mean click probability
+	Top         []Arm     `json:"top"`
+	RefreshedAt time.Time `json:"refreshed_at" example:"2024-03-17T12:00:00Z"`
+}
+
+// Bandit reserves slots of slates for movies picked by Thompson sampling over click statistics,
+// so movies strategies never rank high, new ones especially, get impressions
+type Bandit struct {
+	catalog *Catalog
+	source  MovieStatsSource
+	logger  *slog.Logger
+	cfg     Exploration
+
+	mu    sync.RWMutex
+	stats map[string]slate.MovieStats
+	// pool is movies exploration samples from, it is picked on refresh
+	pool        []movie.Movie
+	refreshedAt time.Time
+
+	slates, explored, items, explorationItems atomic.Int64
+}
+
+func NewBandit(catalog *Catalog, source MovieStatsSource, logger *slog.Logger, cfg Exploration) *Bandit {
+	return &Bandit{
+		catalog: catalog,
+		source:  source,
+		logger:  logger,
+		cfg:     cfg,
+		stats:   make(map[string]slate.MovieStats),
+	}
+}
+
+func (b *Bandit) Refresh(ctx context.Context) error {
+	stats, err := b.source.GetMovieStats(ctx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]slate.MovieStats, len(stats))
+	for _, st := range stats {
+		byID[st.MovieID] = st
+	}
+	b.mu.Lock()
+	b.stats = byID
+	b.pool = b.pickPool(time.Now())
+	b.refreshedAt = time.Now()
+	b.mu.Unlock()
+	return nil
+}
+
+// pickPool returns new movies and then least served ones up to pool size, caller holds lock
+func (b *Bandit) pickPool(now time.Time) []movie.Movie {
+	type candidate struct {
+		movie movie.Movie
+		arm   Arm
+	}
+	candidates := make([]candidate, 0)
+	for _, m := range b.catalog.All() {
+		a, _, _ := b.arm(m.ID, now)
+		candidates = append(candidates, candidate{movie: m, arm: a})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		x, y := candidates[i].arm, candidates[j].arm
+		if x.New != y.New {
+			return x.New
+		}
+		if x.Impressions != y.Impressions {
+			return x.Impressions < y.Impressions
+		}
+		return x.MovieID < y.MovieID
+	})
+	if b.cfg.PoolSize > 0 {
+		candidates = candidates[:min(len(candidates), b.cfg.PoolSize)]
+	}
+	pool := make([]movie.Movie, 0, len(candidates))
+	for _, c := range candidates {
+		pool = append(pool, c.movie)
+	}
+	return pool
+}
+
+// Run refreshes statistics every interval until ctx is done
+func (b *Bandit) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.Refresh(ctx); err != nil {
+				b.logger.Error("failed to refresh bandit", logging.Err(err))
+			}
+		}
+	}
+}
+
+// arm returns Beta posterior of movie, movies missing in stats were created after last refresh
+func (b *Bandit) arm(movieID string, now time.Time) (Arm, float64, float64) {
+	st, ok := b.stats[movieID]
+	a := Arm{
+		MovieID:     movieID,
+		Impressions: st.Impressions,
+		Clicks:      st.Clicks,
+		New:         !ok || now.Sub(st.CreatedAt) < b.cfg.NewMovieWindow,
+	}
+	alpha := b.cfg.PriorClicks
+	if a.New && b.cfg.NewMovieBoost > 0 {
+		alpha *= b.cfg.NewMovieBoost
+	}
+	beta := math.Max(b.cfg.PriorImpressions-b.cfg.PriorClicks, 0)
+	alpha += float64(st.Clicks)
+	beta += float64(max(st.Impressions-st.Clicks, 0))
+	// both parameters of Beta distribution must be positive
+	alpha, beta = math.Max(alpha, 1e-3), math.Max(beta, 1e-3)
+	a.Mean = alpha / (alpha + beta)
+	return a, alpha, beta
+}
+
+// Explore replaces tail of recs with movies sampled among allowed ones and spreads them evenly
+// over the list, returned list has at most limit movies
+func (b *Bandit) Explore(recs []Recommendation, allow func(movieID string) bool, limit int) []Recommendation {
+	if len(recs) > limit {
+		recs = recs[:limit]
+	}
+	var picked []Recommendation
+	if b.cfg.Slots > 0 && limit > 0 {
+		picked = b.sample(recs, allow, min(b.cfg.Slots, limit))
+	}
+	b.slates.Add(1)
+	if len(picked) > 0 {
+		b.explored.Add(1)
+	}
+	b.explorationItems.Add(int64(len(picked)))
+	recs = insertEvenly(recs[:min(len(recs), limit-len(picked))], picked)
+	b.items.Add(int64(len(recs)))
+	return recs
+}
+
+// sample draws click probability of every allowed movie of pool missing in recs and returns n best draws,
+// every call has its own source so that concurrent requests don't wait for each other
+func (b *Bandit) sample(recs []Recommendation, allow func(movieID string) bool, n int) []Recommendation {
+	seen := make(map[string]struct{}, len(recs))
+	for _, rec := range recs {
+		seen[rec.Movie.ID] = struct{}{}
+	}
+	type draw struct {
+		movie       movie.Movie
+		arm         Arm
+		alpha, beta float64
+		sample      float64
+	}
+	now := time.Now()
+	draws := make([]draw, 0)
+	b.mu.RLock()
+	for _, m := range b.pool {
+		if _, ok := seen[m.ID]; ok || !allow(m.ID) {
+			continue
+		}
+		a, alpha, beta := b.arm(m.ID, now)
+		draws = append(draws, draw{movie: m, arm: a, alpha: alpha, beta: beta})
+	}
+	b.mu.RUnlock()
+
+	rng := rand.New(rand.NewSource(now.UnixNano()))
+	for i := range draws {
+		draws[i].sample = sampleBeta(rng, draws[i].alpha, draws[i].beta)
+	}
+
+	sort.Slice(draws, func(i, j int) bool {
+		return draws[i].sample > draws[j].sample
+	})
+	picked := make([]Recommendation, 0, n)
+	for _, d := range draws[:min(n, len(draws))] {
+		picked = append(picked, Recommendation{
+			Movie:       d.movie,
+			Score:       d.sample,
+			Exploration: true,
+			Explanations: []Explanation{{
+				Reason: ReasonExploration,
+				Weight: d.arm.Mean,
+			}},
+		})
+	}
+	return picked
+}
+
+// insertEvenly puts explored movies at positions spread over the whole list, first one not on top
+func insertEvenly(recs, explored []Recommendation) []Recommendation {
+	total := len(recs) + len(explored)
+	positions := make(map[int]Recommendation, len(explored))
+	for k, rec := range explored {
+		positions[(k+1)*total/(len(explored)+1)] = rec
+	}
+	out := make([]Recommendation, 0, total)
+	next := 0
+	for i := 0; i < total; i++ {
+		if rec, ok := positions[i]; ok {
+			out = append(out, rec)
+			continue
+		}
+		out = append(out, recs[next])
+		next++
+	}
+	return out
+}
+
+func (b *Bandit) Metrics() BanditMetrics {
+	m := BanditMetrics{
+		Slots:            b.cfg.Slots,
+		Slates:           b.slates.Load(),
+		Explored:         b.explored.Load(),
+		Items:            b.items.Load(),
+		ExplorationItems: b.explorationItems.Load(),
+	}
+	if m.Items > 0 {
+		m.ExplorationRate = float64(m.ExplorationItems) / float64(m.Items)
+	}
+	now := time.Now()
+	arms := make([]Arm, 0)
+	b.mu.RLock()
+	for _, mv := range b.catalog.All() {
+		a, _, _ := b.arm(mv.ID, now)
+		if a.New {
+			m.NewArms++
+		}
+		arms = append(arms, a)
+	}
+	m.RefreshedAt = b.refreshedAt
+	b.mu.RUnlock()
+	m.Arms = len(arms)
+	sort.Slice(arms, func(i, j int) bool {
+		return arms[i].Mean > arms[j].Mean
+	})
+	m.Top = arms[:min(len(arms), 10)]
+	return m
+}
+
+// sampleBeta draws from Beta(alpha, beta) as ratio of Gamma draws
+func sampleBeta(rng *rand.Rand, alpha, beta float64) float64 {
+	x := sampleGamma(rng, alpha)
+	y := sampleGamma(rng, beta)
+	if x+y == 0 {
+		return 0
+	}
+	return x / (x + y)
+}
+
+// sampleGamma draws from Gamma(shape, 1) using Marsaglia and Tsang method
+func sampleGamma(rng *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		// boost shape and scale result back
+		return sampleGamma(rng, shape+1) * math.Pow(rng.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
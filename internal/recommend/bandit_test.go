@@ -0,0 +1,166 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/slate"
+	"io"
+	"log/slog"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+type staticStats []slate.MovieStats
+
+func (s staticStats) GetMovieStats(context.Context) ([]slate.MovieStats, error) {
+	return s, nil
+}
+
+func TestInsertEvenly(t *testing.T) {
+	recs := func(ids ...string) []Recommendation {
+		out := make([]Recommendation, 0, len(ids))
+		for _, id := range ids {
+			out = append(out, Recommendation{Movie: movie.Movie{ID: id}})
+		}
+		return out
+	}
+	tests := []struct {
+		name     string
+		recs     []Recommendation
+		explored []Recommendation
+		want     string
+	}{
+		{"no exploration", recs("a", "b"), nil, "ab"},
+		{"one in the middle", recs("a", "b", "c"), recs("X"), "abXc"},
+		{"spread", recs("a", "b", "c", "d"), recs("X", "Y"), "abXcYd"},
+		{"only explored", nil, recs("X", "Y"), "XY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			for _, rec := range insertEvenly(tt.recs, tt.explored) {
+				got += rec.Movie.ID
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampleBeta(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tests := []struct {
+		alpha, beta float64
+	}{
+		{1, 19},
+		{0.5, 0.5},
+		{30, 10},
+	}
+	for _, tt := range tests {
+		const n = 20000
+		var sum float64
+		for i := 0; i < n; i++ {
+			x := sampleBeta(rng, tt.alpha, tt.beta)
+			if x < 0 || x > 1 {
+				t.Fatalf("Beta(%v, %v) sample %v out of [0, 1]", tt.alpha, tt.beta, x)
+			}
+			sum += x
+		}
+		want := tt.alpha / (tt.alpha + tt.beta)
+		if got := sum / n; got < want-0.01 || got > want+0.01 {
+			t.Errorf("Beta(%v, %v) mean %v, want %v", tt.alpha, tt.beta, got, want)
+		}
+	}
+}
+
+func TestBandit_Explore(t *testing.T) {
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	movies := []movie.Movie{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "new"}, {ID: "rated"}}
+	catalog := NewCatalog(staticMovies(movies), staticDirectors{})
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stats := staticStats{
+		{MovieID: "a", CreatedAt: old, Impressions: 1000, Clicks: 10},
+		{MovieID: "b", CreatedAt: old, Impressions: 1000, Clicks: 10},
+		{MovieID: "c", CreatedAt: old, Impressions: 1000, Clicks: 5},
+		{MovieID: "new", CreatedAt: time.Now()},
+		{MovieID: "rated", CreatedAt: time.Now()},
+	}
+	bandit := NewBandit(catalog, stats, slog.New(slog.NewTextHandler(io.Discard, nil)), Exploration{
+		Slots:            1,
+		PriorClicks:      1,
+		PriorImpressions: 20,
+		NewMovieBoost:    10,
+		NewMovieWindow:   24 * time.Hour,
+	})
+	if err := bandit.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	allow := func(movieID string) bool {
+		return movieID != "rated"
+	}
+
+	var explored int
+	for i := 0; i < 100; i++ {
+		recs := bandit.Explore([]Recommendation{{Movie: movies[0]}, {Movie: movies[1]}}, allow, 2)
+		if len(recs) != 2 || recs[0].Movie.ID != "a" {
+			t.Fatalf("expected a kept on top and one slot explored: %v", recs)
+		}
+		if !recs[1].Exploration || recs[1].Movie.ID == "rated" || recs[1].Movie.ID == "a" {
+			t.Fatalf("explored movie must be allowed and not in list: %v", recs[1])
+		}
+		if recs[1].Movie.ID == "new" {
+			explored++
+		}
+	}
+	// boosted prior of new movie has mean 0.34 against 0.01 of served ones
+	if explored < 90 {
+		t.Errorf("new movie explored %d times out of 100", explored)
+	}
+	m := bandit.Metrics()
+	if m.Slates != 100 || m.ExplorationItems != 100 || m.ExplorationRate != 0.5 {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+	if m.NewArms != 2 || m.Top[0].MovieID != "new" && m.Top[0].MovieID != "rated" {
+		t.Errorf("unexpected arms: %+v", m)
+	}
+}
+
+func TestBandit_Pool(t *testing.T) {
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	movies := []movie.Movie{{ID: "served"}, {ID: "rare"}, {ID: "new"}, {ID: "unseen"}}
+	catalog := NewCatalog(staticMovies(movies), staticDirectors{})
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stats := staticStats{
+		{MovieID: "served", CreatedAt: old, Impressions: 1000, Clicks: 10},
+		{MovieID: "rare", CreatedAt: old, Impressions: 3},
+		{MovieID: "new", CreatedAt: time.Now(), Impressions: 50},
+		{MovieID: "unseen", CreatedAt: old},
+	}
+	bandit := NewBandit(catalog, stats, slog.New(slog.NewTextHandler(io.Discard, nil)), Exploration{
+		Slots:          1,
+		NewMovieWindow: 24 * time.Hour,
+		PoolSize:       3,
+	})
+	if err := bandit.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := ""
+	for _, m := range bandit.pool {
+		got += m.ID + " "
+	}
+	if got != "new unseen rare " {
+		t.Errorf("expected new movie then least served, got %q", got)
+	}
+	for i := 0; i < 20; i++ {
+		recs := bandit.Explore(nil, func(string) bool { return true }, 1)
+		if len(recs) != 1 || recs[0].Movie.ID == "served" {
+			t.Fatalf("most served movie must be out of pool: %v", recs)
+		}
+	}
+}
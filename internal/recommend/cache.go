@@ -0,0 +1,109 @@
+package recommend
+
+import (
+	"container/list"
+	"github.com/danyatalent/movie-recommend/internal/experiment"
+	"sync"
+	"time"
+)
+
+// Entry is precomputed candidates list of user, exclusions and diversity are applied when it is served
+type Entry struct {
+	Candidates []Candidate
+	// Strategy is name of strategy which produced candidates
+	Strategy   string
+	Assignment experiment.Assignment
+	ComputedAt time.Time
+}
+
+// cached is entry of user with time it was last served
+type cached struct {
+	userID string
+	entry  Entry
+	usedAt time.Time
+}
+
+// Cache keeps precomputed candidates of users in memory, size is number of candidates kept per user.
+// At most users entries are kept, least recently served ones are evicted first
+type Cache struct {
+	size  int
+	users int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order is sorted by last use, most recent first
+	order *list.List
+}
+
+func NewCache(size, users int) *Cache {
+	return &Cache{
+		size:    size,
+		users:   users,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns entry of user and marks it as used
+func (c *Cache) Get(userID string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[userID]
+	if !ok {
+		return Entry{}, false
+	}
+	el.Value.(*cached).usedAt = time.Now()
+	c.order.MoveToFront(el)
+	return el.Value.(*cached).entry, true
+}
+
+// Set stores entry of user, recomputing doesn't count as use so that idle users still expire
+func (c *Cache) Set(userID string, e Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[userID]; ok {
+		el.Value.(*cached).entry = e
+		return
+	}
+	c.entries[userID] = c.order.PushFront(&cached{userID: userID, entry: e, usedAt: time.Now()})
+	for c.users > 0 && c.order.Len() > c.users {
+		c.remove(c.order.Back())
+	}
+}
+
+// RemoveUnusedSince evicts entries not served since t and returns how many were evicted
+func (c *Cache) RemoveUnusedSince(t time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var removed int
+	for el := c.order.Back(); el != nil && el.Value.(*cached).usedAt.Before(t); el = c.order.Back() {
+		c.remove(el)
+		removed++
+	}
+	return removed
+}
+
+// remove drops element, caller holds lock
+func (c *Cache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*cached).userID)
+}
+
+// ComputedBefore returns users whose entries were computed before t
+func (c *Cache) ComputedBefore(t time.Time) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	users := make([]string, 0)
+	for userID, el := range c.entries {
+		if el.Value.(*cached).entry.ComputedAt.Before(t) {
+			users = append(users, userID)
+		}
+	}
+	return users
+}
+
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
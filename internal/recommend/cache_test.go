@@ -0,0 +1,26 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache_Evict(t *testing.T) {
+	c := NewCache(10, 2)
+	c.Set("u1", Entry{})
+	c.Set("u2", Entry{})
+	// u1 becomes most recently served, u2 is evicted by u3
+	if _, ok := c.Get("u1"); !ok {
+		t.Fatal("u1 must be cached")
+	}
+	c.Set("u3", Entry{})
+	if _, ok := c.Get("u2"); ok || c.Len() != 2 {
+		t.Errorf("u2 must be evicted, %d cached", c.Len())
+	}
+
+	// recomputing doesn't count as use
+	c.Set("u1", Entry{ComputedAt: time.Now()})
+	if removed := c.RemoveUnusedSince(time.Now().Add(time.Minute)); removed != 2 || c.Len() != 0 {
+		t.Errorf("expected 2 idle users removed, got %d with %d left", removed, c.Len())
+	}
+}
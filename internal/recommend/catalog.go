@@ -0,0 +1,121 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/director"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"sync"
+)
+
+type MovieSource interface {
+	GetAllMovies(ctx context.Context) ([]movie.Movie, error)
+}
+
+type DirectorSource interface {
+	GetAllDirectors(ctx context.Context) ([]director.Director, error)
+}
+
+// Catalog keeps all movies and their directors in memory so strategies can hydrate
+// and filter candidates without queries, descriptions of movies are kept in text index
+type Catalog struct {
+	source    MovieSource
+	directors DirectorSource
+	text      *TextIndex
+
+	mu            sync.RWMutex
+	movies        map[string]movie.Movie
+	directorsByID map[string]director.Director
+}
+
+func NewCatalog(source MovieSource, directors DirectorSource) *Catalog {
+	return &Catalog{
+		source:        source,
+		directors:     directors,
+		text:          NewTextIndex(),
+		movies:        make(map[string]movie.Movie),
+		directorsByID: make(map[string]director.Director),
+	}
+}
+
+func (c *Catalog) Refresh(ctx context.Context) error {
+	movies, err := c.source.GetAllMovies(ctx)
+	if err != nil {
+		return err
+	}
+	directors, err := c.directors.GetAllDirectors(ctx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]movie.Movie, len(movies))
+	for _, m := range movies {
+		byID[m.ID] = m
+	}
+	directorsByID := make(map[string]director.Director, len(directors))
+	for _, d := range directors {
+		directorsByID[d.ID] = d
+	}
+	c.mu.Lock()
+	previous := c.movies
+	c.movies = byID
+	c.directorsByID = directorsByID
+	c.mu.Unlock()
+
+	// only new, edited and removed descriptions touch text index
+	for id, m := range byID {
+		if old, ok := previous[id]; !ok || old.Description != m.Description {
+			c.text.Upsert(id, m.Description)
+		}
+	}
+	for id := range previous {
+		if _, ok := byID[id]; !ok {
+			c.text.Remove(id)
+		}
+	}
+	return nil
+}
+
+// Upsert puts created or edited movie into catalog right away without waiting for refresh
+func (c *Catalog) Upsert(m movie.Movie) {
+	c.mu.Lock()
+	old, ok := c.movies[m.ID]
+	c.movies[m.ID] = m
+	c.mu.Unlock()
+	if !ok || old.Description != m.Description {
+		c.text.Upsert(m.ID, m.Description)
+	}
+}
+
+// DescriptionSimilarity returns TF-IDF cosine similarity of descriptions of two movies
+func (c *Catalog) DescriptionSimilarity(a, b string) float64 {
+	return c.text.Similarity(a, b)
+}
+
+// SimilarDescriptions returns similarity of description of movie to every movie sharing a word with it
+func (c *Catalog) SimilarDescriptions(movieID string) map[string]float64 {
+	return c.text.Similar(movieID)
+}
+
+// Director returns director of catalog movies by id
+func (c *Catalog) Director(id string) (director.Director, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	d, ok := c.directorsByID[id]
+	return d, ok
+}
+
+func (c *Catalog) Get(id string) (movie.Movie, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	m, ok := c.movies[id]
+	return m, ok
+}
+
+func (c *Catalog) All() []movie.Movie {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	movies := make([]movie.Movie, 0, len(c.movies))
+	for _, m := range c.movies {
+		movies = append(movies, m)
+	}
+	return movies
+}
@@ -0,0 +1,195 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/onboarding"
+	"sort"
+	"sync"
+)
+
+const (
+	// likedScore is the lowest score treated as liking the movie
+	likedScore = 7
+
+	coldPopularityWeight  = 0.2
+	coldGenresWeight      = 0.35
+	coldSeedsWeight       = 0.3
+	coldDescriptionWeight = 0.15
+)
+
+type PreferenceSource interface {
+	GetPreferences(ctx context.Context, userID string) (onboarding.Preferences, error)
+}
+
+// ColdStart ranks movies for users with little history by onboarding picks and popularity
+type ColdStart struct {
+	catalog *Catalog
+	prefs   PreferenceSource
+
+	mu         sync.RWMutex
+	ds         *Dataset
+	popularity map[string]float64
+}
+
+func NewColdStart(catalog *Catalog, prefs PreferenceSource) *ColdStart {
+	return &ColdStart{
+		catalog: catalog,
+		prefs:   prefs,
+	}
+}
+
+func (c *ColdStart) Name() string {
+	return "cold-start"
+}
+
+// Fit computes popularity normalized to [0, 1] by the most rated movie
+func (c *ColdStart) Fit(ds *Dataset) error {
+	popularity := make(map[string]float64)
+	var top float64
+	for _, movieID := range ds.Items() {
+		count := float64(len(ds.ItemRatings(movieID)))
+		popularity[movieID] = count
+		if count > top {
+			top = count
+		}
+	}
+	if top > 0 {
+		for movieID := range popularity {
+			popularity[movieID] /= top
+		}
+	}
+	c.mu.Lock()
+	c.ds = ds
+	c.popularity = popularity
+	c.mu.Unlock()
+	return nil
+}
+
+// Recommend scores movies by favourite genres, similarity of content and description to favourite
+// and liked movies and popularity
+func (c *ColdStart) Recommend(ctx context.Context, q Query) ([]Candidate, error) {
+	c.mu.RLock()
+	ds, popularity := c.ds, c.popularity
+	c.mu.RUnlock()
+	if ds == nil {
+		return nil, ErrNotFitted
+	}
+	prefs, err := c.prefs.GetPreferences(ctx, q.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{})
+	seeds := make([]string, 0, len(prefs.MovieIDs))
+	for _, movieID := range prefs.MovieIDs {
+		seen[movieID] = struct{}{}
+		seeds = append(seeds, movieID)
+	}
+	for movieID, score := range ds.UserRatings(q.UserID) {
+		seen[movieID] = struct{}{}
+		if score >= likedScore {
+			seeds = append(seeds, movieID)
+		}
+	}
+	descriptions := make([]map[string]float64, 0, len(seeds))
+	for _, seedID := range seeds {
+		descriptions = append(descriptions, c.catalog.SimilarDescriptions(seedID))
+	}
+	favourite := make(map[string]struct{}, len(prefs.GenreIDs))
+	for _, genreID := range prefs.GenreIDs {
+		favourite[genreID] = struct{}{}
+	}
+
+	scores := make(map[string]float64)
+	for _, m := range c.catalog.All() {
+		if _, ok := seen[m.ID]; ok || !q.allowed(m.ID) {
+			continue
+		}
+		score := coldPopularityWeight * popularity[m.ID]
+		if len(favourite) > 0 && len(m.Genres) > 0 {
+			var shared int
+			for _, g := range m.Genres {
+				if _, ok := favourite[g.ID]; ok {
+					shared++
+				}
+			}
+			score += coldGenresWeight * float64(shared) / float64(len(m.Genres))
+		}
+		var best float64
+		for _, seedID := range seeds {
+			seed, ok := c.catalog.Get(seedID)
+			if !ok {
+				continue
+			}
+			if s := ContentSimilarity(seed, m).Score; s > best {
+				best = s
+			}
+		}
+		score += coldSeedsWeight * best
+		var text float64
+		for _, similar := range descriptions {
+			text = max(text, similar[m.ID])
+		}
+		score += coldDescriptionWeight * text
+		scores[m.ID] = score
+	}
+	return rank(scores, q.Limit), nil
+}
+
+// Questionnaire picks the most popular movies of every genre, each movie is offered only once
+// so that the whole questionnaire covers as many different movies as possible
+func (c *ColdStart) Questionnaire(perGenre int) []onboarding.GenreMovies {
+	c.mu.RLock()
+	popularity := c.popularity
+	c.mu.RUnlock()
+
+	movies := c.catalog.All()
+	sort.Slice(movies, func(i, j int) bool {
+		pi, pj := popularity[movies[i].ID], popularity[movies[j].ID]
+		if pi != pj {
+			return pi > pj
+		}
+		if movies[i].Rating != movies[j].Rating {
+			return movies[i].Rating > movies[j].Rating
+		}
+		return movies[i].ID < movies[j].ID
+	})
+
+	genres := make(map[string]genre.Genre)
+	for _, m := range movies {
+		for _, g := range m.Genres {
+			genres[g.ID] = g
+		}
+	}
+	questions := make([]onboarding.GenreMovies, 0, len(genres))
+	for _, g := range genres {
+		questions = append(questions, onboarding.GenreMovies{Genre: g, Movies: make([]movie.Movie, 0, perGenre)})
+	}
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].Genre.Name < questions[j].Genre.Name
+	})
+	index := make(map[string]int, len(questions))
+	for i, question := range questions {
+		index[question.Genre.ID] = i
+	}
+
+	// every movie goes to its genre which still has the fewest picks
+	for _, m := range movies {
+		best := -1
+		for _, g := range m.Genres {
+			i := index[g.ID]
+			if len(questions[i].Movies) >= perGenre {
+				continue
+			}
+			if best == -1 || len(questions[i].Movies) < len(questions[best].Movies) {
+				best = i
+			}
+		}
+		if best != -1 {
+			questions[best].Movies = append(questions[best].Movies, m)
+		}
+	}
+	return questions
+}
@@ -0,0 +1,193 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"math"
+	"testing"
+)
+
+func coldStartMovies() []movie.Movie {
+	scifi := genre.Genre{ID: "scifi", Name: "Sci-Fi"}
+	romance := genre.Genre{ID: "romance", Name: "Romance"}
+	comedy := genre.Genre{ID: "comedy", Name: "Comedy"}
+	drama := genre.Genre{ID: "drama", Name: "Drama"}
+	return []movie.Movie{
+		{ID: "dune", DirectorID: "villeneuve", Genres: []genre.Genre{scifi},
+			Description: "desert planet spice empire war"},
+		{ID: "arrival", DirectorID: "villeneuve", Genres: []genre.Genre{scifi},
+			Description: "linguist aliens language contact"},
+		{ID: "notebook", DirectorID: "cassavetes", Genres: []genre.Genre{romance},
+			Description: "summer letters"},
+		{ID: "titanic", DirectorID: "cameron", Genres: []genre.Genre{romance},
+			Description: "ship iceberg ocean"},
+		{ID: "spoof", DirectorID: "brooks", Genres: []genre.Genre{comedy},
+			Description: "desert planet spice empire war parody"},
+		{ID: "popular", DirectorID: "nolan", Genres: []genre.Genre{drama},
+			Description: "magicians rivalry"},
+	}
+}
+
+func TestColdStart_Recommend(t *testing.T) {
+	catalog := NewCatalog(staticMovies(coldStartMovies()), staticDirectors{})
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ds := NewDataset([]rating.Rating{
+		{UserID: "u1", MovieID: "popular", Score: 8},
+		{UserID: "u2", MovieID: "popular", Score: 8},
+		{UserID: "u3", MovieID: "notebook", Score: 8},
+		{UserID: "fan", MovieID: "dune", Score: 9},
+	})
+
+	tests := []struct {
+		name   string
+		userID string
+		prefs  staticPrefs
+		// first is expected top movie, every pair in before is ranked in that order
+		first  string
+		before [][2]string
+		// skip are movies which must not be recommended
+		skip []string
+	}{
+		{
+			name:   "popularity without preferences",
+			userID: "new",
+			first:  "popular",
+			before: [][2]string{{"notebook", "titanic"}},
+		},
+		{
+			name:   "favourite genre",
+			userID: "new",
+			prefs:  staticPrefs{GenreIDs: []string{"romance"}},
+			first:  "notebook",
+			before: [][2]string{{"titanic", "popular"}},
+		},
+		{
+			name:   "picked movie seeds similar ones",
+			userID: "new",
+			prefs:  staticPrefs{MovieIDs: []string{"dune"}},
+			first:  "arrival",
+			// spoof shares only description with dune, titanic shares nothing
+			before: [][2]string{{"spoof", "titanic"}},
+			skip:   []string{"dune"},
+		},
+		{
+			name:   "liked movie seeds like picked one",
+			userID: "fan",
+			first:  "arrival",
+			before: [][2]string{{"spoof", "titanic"}},
+			skip:   []string{"dune"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewColdStart(catalog, tt.prefs)
+			if err := c.Fit(ds); err != nil {
+				t.Fatal(err)
+			}
+			candidates, err := c.Recommend(context.Background(), Query{UserID: tt.userID, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(candidates) == 0 || candidates[0].MovieID != tt.first {
+				t.Fatalf("expected %s first, got %v", tt.first, candidates)
+			}
+			position := make(map[string]int, len(candidates))
+			for i, c := range candidates {
+				position[c.MovieID] = i
+			}
+			for _, pair := range tt.before {
+				a, okA := position[pair[0]]
+				b, okB := position[pair[1]]
+				if !okA || !okB || a > b {
+					t.Errorf("expected %s before %s, got %v", pair[0], pair[1], candidates)
+				}
+			}
+			for _, id := range tt.skip {
+				if _, ok := position[id]; ok {
+					t.Errorf("%s must not be recommended: %v", id, candidates)
+				}
+			}
+		})
+	}
+}
+
+func TestColdStart_DescriptionWeight(t *testing.T) {
+	catalog := NewCatalog(staticMovies(coldStartMovies()), staticDirectors{})
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	c := NewColdStart(catalog, staticPrefs{MovieIDs: []string{"dune"}})
+	if err := c.Fit(NewDataset(nil)); err != nil {
+		t.Fatal(err)
+	}
+	candidates, err := c.Recommend(context.Background(), Query{UserID: "new", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, candidate := range candidates {
+		if candidate.MovieID != "spoof" {
+			continue
+		}
+		// spoof matches dune by description only
+		if want := coldDescriptionWeight * catalog.DescriptionSimilarity("dune", "spoof"); math.Abs(candidate.Score-want) > 1e-9 || want == 0 {
+			t.Errorf("spoof score = %f, want %f", candidate.Score, want)
+		}
+		return
+	}
+	t.Errorf("spoof must be recommended: %v", candidates)
+}
+
+func TestColdStart_Questionnaire(t *testing.T) {
+	drama := genre.Genre{ID: "drama", Name: "Drama"}
+	comedy := genre.Genre{ID: "comedy", Name: "Comedy"}
+	movies := []movie.Movie{
+		{ID: "a", Genres: []genre.Genre{drama, comedy}},
+		{ID: "b", Genres: []genre.Genre{drama, comedy}},
+		{ID: "c", Genres: []genre.Genre{drama}},
+		{ID: "d", Genres: []genre.Genre{comedy}},
+		{ID: "e", Genres: []genre.Genre{drama}},
+	}
+	catalog := NewCatalog(staticMovies(movies), staticDirectors{})
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	c := NewColdStart(catalog, staticPrefs{})
+	// popularity order is a, b, c, d, e
+	ratings := make([]rating.Rating, 0)
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		for u := 0; u < 5-i; u++ {
+			ratings = append(ratings, rating.Rating{UserID: string(rune('p' + u)), MovieID: id, Score: 8})
+		}
+	}
+	if err := c.Fit(NewDataset(ratings)); err != nil {
+		t.Fatal(err)
+	}
+
+	questions := c.Questionnaire(2)
+	if len(questions) != 2 || questions[0].Genre.ID != "comedy" || questions[1].Genre.ID != "drama" {
+		t.Fatalf("expected comedy and drama questions, got %+v", questions)
+	}
+	offered := make(map[string]string)
+	for _, q := range questions {
+		if len(q.Movies) != 2 {
+			t.Errorf("%s must offer 2 movies: %v", q.Genre.ID, q.Movies)
+		}
+		for _, m := range q.Movies {
+			if prev, ok := offered[m.ID]; ok {
+				t.Errorf("movie %s is offered in both %s and %s", m.ID, prev, q.Genre.ID)
+			}
+			offered[m.ID] = q.Genre.ID
+		}
+	}
+	// a and b fit both genres and are split between them, the rest fill remaining slots by popularity
+	if offered["a"] == offered["b"] || offered["c"] != "drama" || offered["d"] != "comedy" {
+		t.Errorf("unexpected questionnaire: %v", offered)
+	}
+	if _, ok := offered["e"]; ok {
+		t.Errorf("e must not fit, both genres are full: %v", offered)
+	}
+}
@@ -0,0 +1,184 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"github.com/danyatalent/movie-recommend/internal/user"
+	"math"
+	"sort"
+)
+
+const (
+	// correlationWeight is the most share of correlation in compatibility, it is reached gradually
+	// as users co-rate more movies, correlationShrinkage co-rated movies give half of it
+	correlationWeight    = 0.6
+	correlationShrinkage = 5
+	// disagreementDelta is the least difference of scores reported as disagreement
+	disagreementDelta = 3
+	// compatibilityMovies is the most movies in both loved and disagreements lists
+	compatibilityMovies = 10
+)
+
+type UserGetter interface {
+	GetUserByID(ctx context.Context, id string) (user.User, error)
+}
+
+// MovieScores are scores two users gave to the same movie
+type MovieScores struct {
+	Movie      movie.Movie `json:"movie"`
+	Score      int         `json:"score" example:"9"`
+	OtherScore int         `json:"other_score" example:"4"`
+}
+
+// Compatibility of two users, Percent blends correlation of co-rated movies with overlap of genre
+// affinities, correlation counts more the more movies users co-rated
+type Compatibility struct {
+	User    user.User `json:"user"`
+	Other   user.User `json:"other"`
+	Percent int       `json:"percent" example:"78"`
+	// Correlation is Pearson correlation of scores of co-rated movies in [-1, 1]
+	Correlation float64 `json:"correlation" example:"0.64"`
+	// GenreOverlap is shared share of genre affinities in [0, 1]
+	GenreOverlap  float64       `json:"genre_overlap" example:"0.71"`
+	CoRated       int           `json:"co_rated" example:"12"`
+	BothLoved     []MovieScores `json:"both_loved"`
+	Disagreements []MovieScores `json:"disagreements"`
+}
+
+// Compatibilities compares rating histories and taste profiles of users
+type Compatibilities struct {
+	catalog *Catalog
+	users   UserGetter
+	ratings UserRatingSource
+	taste   TasteSource
+}
+
+func NewCompatibilities(catalog *Catalog, users UserGetter, ratings UserRatingSource, taste TasteSource) *Compatibilities {
+	return &Compatibilities{
+		catalog: catalog,
+		users:   users,
+		ratings: ratings,
+		taste:   taste,
+	}
+}
+
+// Compare returns compatibility of two users, missing user is reported as apperror.ErrEntityNotFound
+func (c *Compatibilities) Compare(ctx context.Context, userID, otherID string) (Compatibility, error) {
+	u, err := c.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return Compatibility{}, err
+	}
+	other, err := c.users.GetUserByID(ctx, otherID)
+	if err != nil {
+		return Compatibility{}, err
+	}
+	ratings, err := c.ratings.GetRatingsByUser(ctx, userID)
+	if err != nil {
+		return Compatibility{}, err
+	}
+	otherRatings, err := c.ratings.GetRatingsByUser(ctx, otherID)
+	if err != nil {
+		return Compatibility{}, err
+	}
+
+	result := Compatibility{
+		User:          user.User{ID: u.ID, Name: u.Name},
+		Other:         user.User{ID: other.ID, Name: other.Name},
+		BothLoved:     make([]MovieScores, 0),
+		Disagreements: make([]MovieScores, 0),
+	}
+	scores := make(map[string]int, len(ratings))
+	for _, rt := range ratings {
+		scores[rt.MovieID] = rt.Score
+	}
+	shared := make([]MovieScores, 0)
+	for _, rt := range otherRatings {
+		score, ok := scores[rt.MovieID]
+		if !ok {
+			continue
+		}
+		m, ok := c.catalog.Get(rt.MovieID)
+		if !ok {
+			m = movie.Movie{ID: rt.MovieID}
+		}
+		shared = append(shared, MovieScores{Movie: m, Score: score, OtherScore: rt.Score})
+	}
+	result.CoRated = len(shared)
+	result.Correlation = correlation(shared)
+	result.GenreOverlap = genreOverlap(c.taste.Profile(userID), c.taste.Profile(otherID))
+
+	weight := correlationWeight * float64(len(shared)) / float64(len(shared)+correlationShrinkage)
+	percent := weight*(result.Correlation+1)/2 + (1-weight)*result.GenreOverlap
+	result.Percent = int(math.Round(100 * percent))
+
+	for _, s := range shared {
+		if s.Score >= likedScore && s.OtherScore >= likedScore {
+			result.BothLoved = append(result.BothLoved, s)
+		}
+		if abs(s.Score-s.OtherScore) >= disagreementDelta {
+			result.Disagreements = append(result.Disagreements, s)
+		}
+	}
+	sort.Slice(result.BothLoved, func(i, j int) bool {
+		a, b := result.BothLoved[i], result.BothLoved[j]
+		if a.Score+a.OtherScore != b.Score+b.OtherScore {
+			return a.Score+a.OtherScore > b.Score+b.OtherScore
+		}
+		return a.Movie.ID < b.Movie.ID
+	})
+	sort.Slice(result.Disagreements, func(i, j int) bool {
+		a, b := result.Disagreements[i], result.Disagreements[j]
+		if abs(a.Score-a.OtherScore) != abs(b.Score-b.OtherScore) {
+			return abs(a.Score-a.OtherScore) > abs(b.Score-b.OtherScore)
+		}
+		return a.Movie.ID < b.Movie.ID
+	})
+	result.BothLoved = result.BothLoved[:min(len(result.BothLoved), compatibilityMovies)]
+	result.Disagreements = result.Disagreements[:min(len(result.Disagreements), compatibilityMovies)]
+	return result, nil
+}
+
+// correlation is Pearson correlation of scores, when scores of a user don't vary it falls back
+// to mean difference of scores mapped onto the same range
+func correlation(shared []MovieScores) float64 {
+	if len(shared) == 0 {
+		return 0
+	}
+	var meanA, meanB float64
+	for _, s := range shared {
+		meanA += float64(s.Score)
+		meanB += float64(s.OtherScore)
+	}
+	meanA /= float64(len(shared))
+	meanB /= float64(len(shared))
+	var cov, varA, varB, diff float64
+	for _, s := range shared {
+		a, b := float64(s.Score)-meanA, float64(s.OtherScore)-meanB
+		cov += a * b
+		varA += a * a
+		varB += b * b
+		diff += math.Abs(float64(s.Score - s.OtherScore))
+	}
+	if varA == 0 || varB == 0 {
+		diff /= float64(len(shared))
+		return 1 - 2*diff/(rating.MaxScore-rating.MinScore)
+	}
+	return cov / math.Sqrt(varA*varB)
+}
+
+// genreOverlap sums the smaller affinity of every genre, affinities of profile sum up to 1
+func genreOverlap(a, b TasteProfile) float64 {
+	var overlap float64
+	for _, g := range a.Genres {
+		overlap += math.Min(g.Affinity, b.GenreAffinity(g.Genre.ID))
+	}
+	return math.Min(overlap, 1)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
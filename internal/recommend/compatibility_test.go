@@ -0,0 +1,93 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"github.com/danyatalent/movie-recommend/internal/user"
+	"math"
+	"testing"
+)
+
+type staticUsers map[string]user.User
+
+func (s staticUsers) GetUserByID(_ context.Context, id string) (user.User, error) {
+	u, ok := s[id]
+	if !ok {
+		return user.User{}, apperror.ErrEntityNotFound
+	}
+	return u, nil
+}
+
+type ratingsByUser map[string][]rating.Rating
+
+func (s ratingsByUser) GetRatingsByUser(_ context.Context, userID string) ([]rating.Rating, error) {
+	return s[userID], nil
+}
+
+func TestCorrelation(t *testing.T) {
+	tests := []struct {
+		name   string
+		shared []MovieScores
+		want   float64
+	}{
+		{"none", nil, 0},
+		{"same", []MovieScores{{Score: 2, OtherScore: 3}, {Score: 8, OtherScore: 9}}, 1},
+		{"opposite", []MovieScores{{Score: 2, OtherScore: 9}, {Score: 9, OtherScore: 2}}, -1},
+		{"constant equal", []MovieScores{{Score: 7, OtherScore: 7}, {Score: 7, OtherScore: 7}}, 1},
+		{"constant apart", []MovieScores{{Score: 1, OtherScore: 10}}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := correlation(tt.shared); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("correlation() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompatibilities_Compare(t *testing.T) {
+	drama := genre.Genre{ID: "drama"}
+	comedy := genre.Genre{ID: "comedy"}
+	users := staticUsers{
+		"u1": {ID: "u1", Name: "first", Email: "first@mail.com"},
+		"u2": {ID: "u2", Name: "second", Email: "second@mail.com"},
+	}
+	ratings := ratingsByUser{
+		"u1": {{MovieID: "a", Score: 9}, {MovieID: "b", Score: 8}, {MovieID: "c", Score: 2}, {MovieID: "d", Score: 5}},
+		"u2": {{MovieID: "a", Score: 10}, {MovieID: "b", Score: 7}, {MovieID: "c", Score: 8}, {MovieID: "e", Score: 6}},
+	}
+	taste := staticTaste{
+		"u1": {Genres: []GenreAffinity{{Genre: drama, Affinity: 0.75}, {Genre: comedy, Affinity: 0.25}}},
+		"u2": {Genres: []GenreAffinity{{Genre: drama, Affinity: 0.5}, {Genre: comedy, Affinity: 0.5}}},
+	}
+	c := NewCompatibilities(NewCatalog(staticMovies(nil), staticDirectors{}), users, ratings, taste)
+
+	got, err := c.Compare(context.Background(), "u1", "u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CoRated != 3 || math.Abs(got.GenreOverlap-0.75) > 1e-9 {
+		t.Errorf("expected 3 co-rated and 0.75 overlap, got %d and %f", got.CoRated, got.GenreOverlap)
+	}
+	if got.User.Email != "" || got.Other.Email != "" {
+		t.Errorf("emails must not be exposed: %+v %+v", got.User, got.Other)
+	}
+	if len(got.BothLoved) != 2 || got.BothLoved[0].Movie.ID != "a" || got.BothLoved[1].Movie.ID != "b" {
+		t.Errorf("a then b must be loved by both: %+v", got.BothLoved)
+	}
+	if len(got.Disagreements) != 1 || got.Disagreements[0].Movie.ID != "c" {
+		t.Errorf("c must be the only disagreement: %+v", got.Disagreements)
+	}
+	weight := correlationWeight * 3 / (3 + correlationShrinkage)
+	want := int(math.Round(100 * (weight*(got.Correlation+1)/2 + (1-weight)*0.75)))
+	if got.Percent != want {
+		t.Errorf("expected %d percent, got %d", want, got.Percent)
+	}
+
+	if _, err = c.Compare(context.Background(), "u1", "unknown"); !errors.Is(err, apperror.ErrEntityNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
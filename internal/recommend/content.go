@@ -0,0 +1,139 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/exclusion"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"math"
+	"sort"
+)
+
+// Features which can be reported as matched between two movies
+const (
+	FeatureGenres   = "genres"
+	FeatureDirector = "director"
+	FeatureDuration = "duration"
+	FeatureRating   = "rating"
+	// FeatureDescription is reported for movies with close plots
+	FeatureDescription = "description"
+)
+
+const (
+	genresWeight   = 0.5
+	directorWeight = 0.3
+	durationWeight = 0.1
+	ratingWeight   = 0.1
+
+	// closeDurationRatio and closeRatingDelta define when duration and rating are reported as matched
+	closeDurationRatio = 0.2
+	closeRatingDelta   = 1.0
+
+	// descriptionWeight is share of description similarity in score of similar movies,
+	// movies with description similarity of at least closeDescription are similar even without shared genres
+	descriptionWeight = 0.3
+	closeDescription  = 0.2
+)
+
+type Similar struct {
+	Movie        movie.Movie   `json:"movie"`
+	Score        float64       `json:"score" example:"0.73"`
+	Matched      []string      `json:"matched" example:"genres,director"`
+	SharedGenres []genre.Genre `json:"shared_genres,omitempty"`
+}
+
+// ContentSimilarity scores overlap of two movies in [0, 1] and reports which features matched
+func ContentSimilarity(a, b movie.Movie) Similar {
+	s := Similar{Movie: b, Matched: make([]string, 0)}
+
+	genresA := make(map[string]struct{}, len(a.Genres))
+	for _, g := range a.Genres {
+		genresA[g.ID] = struct{}{}
+	}
+	for _, g := range b.Genres {
+		if _, ok := genresA[g.ID]; ok {
+			s.SharedGenres = append(s.SharedGenres, g)
+		}
+	}
+	if union := len(a.Genres) + len(b.Genres) - len(s.SharedGenres); union > 0 && len(s.SharedGenres) > 0 {
+		s.Score += genresWeight * float64(len(s.SharedGenres)) / float64(union)
+		s.Matched = append(s.Matched, FeatureGenres)
+	}
+
+	if a.DirectorID != "" && a.DirectorID == b.DirectorID {
+		s.Score += directorWeight
+		s.Matched = append(s.Matched, FeatureDirector)
+	}
+
+	if longest := math.Max(float64(a.Duration), float64(b.Duration)); longest > 0 {
+		diff := math.Abs(float64(a.Duration-b.Duration)) / longest
+		s.Score += durationWeight * (1 - diff)
+		if diff <= closeDurationRatio {
+			s.Matched = append(s.Matched, FeatureDuration)
+		}
+	}
+
+	// rating is 0 until movie is rated, unrated movies are not close by rating
+	if a.Rating > 0 && b.Rating > 0 {
+		diff := math.Abs(a.Rating - b.Rating)
+		s.Score += ratingWeight * math.Max(0, 1-diff/10)
+		if diff <= closeRatingDelta {
+			s.Matched = append(s.Matched, FeatureRating)
+		}
+	}
+	return s
+}
+
+// Content ranks catalog movies by content overlap, it needs no ratings at all
+type Content struct {
+	catalog    *Catalog
+	exclusions exclusion.Source
+}
+
+func NewContent(catalog *Catalog, exclusions exclusion.Source) *Content {
+	return &Content{
+		catalog:    catalog,
+		exclusions: exclusions,
+	}
+}
+
+// Similar returns movies similar to movieID by content and description, movies sharing neither genre
+// nor director nor close description are skipped, userID is optional and hides movies that user is not interested in
+func (c *Content) Similar(ctx context.Context, movieID, userID string, limit int) ([]Similar, error) {
+	source, ok := c.catalog.Get(movieID)
+	if !ok {
+		return nil, apperror.ErrEntityNotFound
+	}
+	excluded, err := exclusion.Load(ctx, c.exclusions, userID)
+	if err != nil {
+		return nil, err
+	}
+	descriptions := c.catalog.SimilarDescriptions(source.ID)
+	similar := make([]Similar, 0)
+	for _, m := range c.catalog.All() {
+		if m.ID == source.ID || excluded.Excludes(m) {
+			continue
+		}
+		s := ContentSimilarity(source, m)
+		text := descriptions[m.ID]
+		if len(s.SharedGenres) == 0 && source.DirectorID != m.DirectorID && text < closeDescription {
+			continue
+		}
+		s.Score = (1-descriptionWeight)*s.Score + descriptionWeight*text
+		if text >= closeDescription {
+			s.Matched = append(s.Matched, FeatureDescription)
+		}
+		similar = append(similar, s)
+	}
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Score != similar[j].Score {
+			return similar[i].Score > similar[j].Score
+		}
+		return similar[i].Movie.ID < similar[j].Movie.ID
+	})
+	if limit > 0 && len(similar) > limit {
+		similar = similar[:limit]
+	}
+	return similar, nil
+}
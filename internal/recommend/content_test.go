@@ -0,0 +1,48 @@
+package recommend
+
+import (
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"testing"
+)
+
+func TestContentSimilarity(t *testing.T) {
+	scifi := genre.Genre{ID: "scifi", Name: "Sci-Fi"}
+	drama := genre.Genre{ID: "drama", Name: "Drama"}
+	dune := movie.Movie{ID: "dune", Duration: 9300, Rating: 8.0, DirectorID: "villeneuve", Genres: []genre.Genre{scifi, drama}}
+	arrival := movie.Movie{ID: "arrival", Duration: 6960, Rating: 7.9, DirectorID: "villeneuve", Genres: []genre.Genre{scifi}}
+	notebook := movie.Movie{ID: "notebook", Duration: 7380, Rating: 5.0, DirectorID: "cassavetes", Genres: []genre.Genre{drama}}
+
+	s := ContentSimilarity(dune, arrival)
+	if len(s.SharedGenres) != 1 || s.SharedGenres[0].ID != "scifi" {
+		t.Errorf("wrong shared genres: %v", s.SharedGenres)
+	}
+	if !containsFeature(s.Matched, FeatureDirector) || !containsFeature(s.Matched, FeatureRating) {
+		t.Errorf("director and rating must match: %v", s.Matched)
+	}
+	if containsFeature(s.Matched, FeatureDuration) {
+		t.Errorf("duration must not match: %v", s.Matched)
+	}
+	if other := ContentSimilarity(dune, notebook); other.Score >= s.Score {
+		t.Errorf("arrival must be closer to dune than notebook: %f >= %f", other.Score, s.Score)
+	}
+
+	// unrated movies get no rating score and don't match by rating
+	unratedA, unratedB := movie.Movie{ID: "a"}, movie.Movie{ID: "b"}
+	if u := ContentSimilarity(unratedA, unratedB); u.Score != 0 || containsFeature(u.Matched, FeatureRating) {
+		t.Errorf("unrated movies must not match: %+v", u)
+	}
+	unratedA.Rating = 7.5
+	if u := ContentSimilarity(unratedA, unratedB); u.Score != 0 || containsFeature(u.Matched, FeatureRating) {
+		t.Errorf("rated and unrated movies must not match by rating: %+v", u)
+	}
+}
+
+func containsFeature(features []string, feature string) bool {
+	for _, f := range features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package recommend
+
+import (
+	"github.com/danyatalent/movie-recommend/internal/rating"
+)
+
+// Dataset is in-memory user-item matrix built from ratings
+type Dataset struct {
+	users map[string]map[string]float64
+	items map[string]map[string]float64
+	means map[string]float64
+}
+
+func NewDataset(ratings []rating.Rating) *Dataset {
+	ds := &Dataset{
+		users: make(map[string]map[string]float64),
+		items: make(map[string]map[string]float64),
+		means: make(map[string]float64),
+	}
+	for _, rt := range ratings {
+		ds.add(rt.UserID, rt.MovieID, float64(rt.Score))
+	}
+	ds.computeMeans()
+	return ds
+}
+
+func (d *Dataset) add(userID, movieID string, score float64) {
+	if d.users[userID] == nil {
+		d.users[userID] = make(map[string]float64)
+	}
+	if d.items[movieID] == nil {
+		d.items[movieID] = make(map[string]float64)
+	}
+	d.users[userID][movieID] = score
+	d.items[movieID][userID] = score
+}
+
+func (d *Dataset) computeMeans() {
+	for userID, scores := range d.users {
+		var sum float64
+		for _, s := range scores {
+			sum += s
+		}
+		d.means[userID] = sum / float64(len(scores))
+	}
+}
+
+// UserRatings returns movie -> score map of user, must not be modified
+func (d *Dataset) UserRatings(userID string) map[string]float64 {
+	return d.users[userID]
+}
+
+// ItemRatings returns user -> score map of movie, must not be modified
+func (d *Dataset) ItemRatings(movieID string) map[string]float64 {
+	return d.items[movieID]
+}
+
+func (d *Dataset) UserMean(userID string) float64 {
+	return d.means[userID]
+}
+
+func (d *Dataset) Users() []string {
+	users := make([]string, 0, len(d.users))
+	for id := range d.users {
+		users = append(users, id)
+	}
+	return users
+}
+
+func (d *Dataset) Items() []string {
+	items := make([]string, 0, len(d.items))
+	for id := range d.items {
+		items = append(items, id)
+	}
+	return items
+}
@@ -0,0 +1,191 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/recommend"
+	"github.com/danyatalent/movie-recommend/pkg/client/postgresql"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"log/slog"
+	"time"
+)
+
+const algorithmALS = "als"
+
+// ErrNoPreviousModel is returned on rollback of the only active model, servers would keep serving it anyway
+var ErrNoPreviousModel = errors.New("no previous model to roll back to")
+
+// Repository stores versions of factor models and tracks user activity for precomputed recommendations
+type Repository struct {
+	client postgresql.Client
+	logger *slog.Logger
+}
+
+func NewRepository(client postgresql.Client, logger *slog.Logger) *Repository {
+	return &Repository{
+		client: client,
+		logger: logger,
+	}
+}
+
+// SaveModel stores model with its factors as new active version
+func (r *Repository) SaveModel(ctx context.Context, model *recommend.FactorModel) (string, error) {
+	q := `insert into models(algorithm, factors, iterations, lambda, global_mean, train_rmse, test_rmse, ratings)
+		  values ($1, $2, $3, $4, $5, $6, $7, $8) returning id, created_at`
+	r.logger.Info("saving model", slog.String("query", q))
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, q, algorithmALS, model.Params.Factors, model.Params.Iterations, model.Params.Lambda,
+		model.GlobalMean, model.Metrics.TrainRMSE, model.Metrics.TestRMSE, model.Metrics.Ratings).
+		Scan(&model.Version, &model.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Code: %s, SQLState: %s",
+				pgErr.Message, pgErr.Detail, pgErr.Code, pgErr.SQLState()))
+			r.logger.Error("error due query", logging.Err(newErr))
+			return "", newErr
+		}
+		return "", err
+	}
+
+	if err = copyFactors(ctx, tx, "model_user_factors", "user_id", model.Version, model.Users); err != nil {
+		return "", fmt.Errorf("can't save user factors: %w", err)
+	}
+	if err = copyFactors(ctx, tx, "model_item_factors", "movie_id", model.Version, model.Items); err != nil {
+		return "", fmt.Errorf("can't save item factors: %w", err)
+	}
+	return model.Version, tx.Commit(ctx)
+}
+
+func copyFactors(ctx context.Context, tx pgx.Tx, table, column, version string, factors map[string][]float64) error {
+	rows := make([][]any, 0, len(factors))
+	for id, vec := range factors {
+		rows = append(rows, []any{version, id, vec})
+	}
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{table}, []string{"model_id", column, "factors"}, pgx.CopyFromRows(rows))
+	return err
+}
+
+// GetActiveModelVersion returns latest model which was not rolled back
+func (r *Repository) GetActiveModelVersion(ctx context.Context) (string, error) {
+	q := "select id from models where active order by created_at desc limit 1"
+	r.logger.Debug("getting active model version", slog.String("query", q))
+	var version string
+	if err := r.client.QueryRow(ctx, q).Scan(&version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", apperror.ErrEntityNotFound
+		}
+		return "", err
+	}
+	return version, nil
+}
+
+func (r *Repository) LoadModel(ctx context.Context, version string) (*recommend.FactorModel, error) {
+	q := `select id, factors, iterations, lambda, global_mean, train_rmse, test_rmse, ratings, created_at
+		  from models where id = $1`
+	r.logger.Info("loading model", slog.String("version", version))
+	model := &recommend.FactorModel{}
+	err := r.client.QueryRow(ctx, q, version).Scan(&model.Version, &model.Params.Factors, &model.Params.Iterations,
+		&model.Params.Lambda, &model.GlobalMean, &model.Metrics.TrainRMSE, &model.Metrics.TestRMSE,
+		&model.Metrics.Ratings, &model.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.ErrEntityNotFound
+		}
+		return nil, err
+	}
+	if model.Users, err = r.loadFactors(ctx, "select user_id, factors from model_user_factors where model_id = $1", version); err != nil {
+		return nil, err
+	}
+	if model.Items, err = r.loadFactors(ctx, "select movie_id, factors from model_item_factors where model_id = $1", version); err != nil {
+		return nil, err
+	}
+	model.Metrics.Users = len(model.Users)
+	model.Metrics.Items = len(model.Items)
+	return model, nil
+}
+
+func (r *Repository) loadFactors(ctx context.Context, q, version string) (map[string][]float64, error) {
+	rows, err := r.client.Query(ctx, q, version)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	factors := make(map[string][]float64)
+	for rows.Next() {
+		var (
+			id  string
+			vec []float64
+		)
+		if err = rows.Scan(&id, &vec); err != nil {
+			return nil, err
+		}
+		factors[id] = vec
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return factors, nil
+}
+
+// RollbackModel deactivates active model so that previous version becomes active, returns deactivated version.
+// Rollback is rejected when there is no previous active version
+func (r *Repository) RollbackModel(ctx context.Context) (string, error) {
+	q := `update models set active = false
+		  where id = (select id from models where active order by created_at desc limit 1)
+		    and (select count(*) from models where active) > 1
+		  returning id`
+	r.logger.Info("rolling back model", slog.String("query", q))
+	var version string
+	if err := r.client.QueryRow(ctx, q).Scan(&version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNoPreviousModel
+		}
+		return "", err
+	}
+	return version, nil
+}
+
+// GetActiveUsers returns users who rated, interacted, changed watchlist or onboarding picks since given time,
+// impressions are skipped since server logs them itself on every served list
+func (r *Repository) GetActiveUsers(ctx context.Context, since time.Time) ([]recommend.Activity, error) {
+	q := `select user_id, max(at) from (
+			  select user_id, rated_at as at from ratings where rated_at >= $1
+			  union all
+			  select user_id, occurred_at from events where occurred_at >= $1 and type <> 'impression'
+			  union all
+			  select user_id, added_at from watchlist where added_at >= $1
+			  union all
+			  select user_id, created_at from user_genre_preferences where created_at >= $1
+			  union all
+			  select user_id, created_at from user_movie_preferences where created_at >= $1
+		  ) a
+		  group by user_id`
+	r.logger.Debug("getting active users", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	activity := make([]recommend.Activity, 0)
+	for rows.Next() {
+		var a recommend.Activity
+		if err = rows.Scan(&a.UserID, &a.At); err != nil {
+			return nil, err
+		}
+		activity = append(activity, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
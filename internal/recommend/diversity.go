@@ -0,0 +1,126 @@
+package recommend
+
+import (
+	"math"
+)
+
+// candidatesPerSlot is how many candidates are generated for every requested slot,
+// re-ranking needs spare candidates to trade relevance for diversity
+const candidatesPerSlot = 3
+
+// Diversity configures maximal marginal relevance re-ranking
+type Diversity struct {
+	// Lambda in [0, 1] weighs relevance against novelty to already picked movies, 1 keeps original order
+	Lambda float64 `json:"lambda" example:"0.7"`
+	// MaxPerDirector and MaxPerGenre are hard caps on picked movies, 0 means no cap
+	MaxPerDirector int `json:"max_per_director" example:"3"`
+	MaxPerGenre    int `json:"max_per_genre" example:"0"`
+}
+
+// FeatureSimilarity compares movies by genres jaccard and same director, result is in [0, 1]
+func FeatureSimilarity(a, b Recommendation) float64 {
+	var genres float64
+	if len(a.Movie.Genres) > 0 || len(b.Movie.Genres) > 0 {
+		ids := make(map[string]struct{}, len(a.Movie.Genres))
+		for _, g := range a.Movie.Genres {
+			ids[g.ID] = struct{}{}
+		}
+		var shared int
+		for _, g := range b.Movie.Genres {
+			if _, ok := ids[g.ID]; ok {
+				shared++
+			}
+		}
+		genres = float64(shared) / float64(len(a.Movie.Genres)+len(b.Movie.Genres)-shared)
+	}
+	var director float64
+	if a.Movie.DirectorID != "" && a.Movie.DirectorID == b.Movie.DirectorID {
+		director = 1
+	}
+	return (genres + director) / 2
+}
+
+// Rerank greedily picks limit recommendations maximizing
+// lambda*relevance - (1-lambda)*max similarity to picked ones, skipping those breaking caps.
+// Relevance is score min-max normalized over recommendations so that every strategy has the same scale
+func Rerank(recommendations []Recommendation, d Diversity, limit int) []Recommendation {
+	if len(recommendations) == 0 {
+		return recommendations
+	}
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, r := range recommendations {
+		low, high = math.Min(low, r.Score), math.Max(high, r.Score)
+	}
+	relevance := make([]float64, len(recommendations))
+	for i, r := range recommendations {
+		relevance[i] = 1
+		if high > low {
+			relevance[i] = (r.Score - low) / (high - low)
+		}
+	}
+
+	picked := make([]Recommendation, 0, limit)
+	used := make([]bool, len(recommendations))
+	// maxSim keeps max similarity of every candidate to picked ones, updated after every pick
+	maxSim := make([]float64, len(recommendations))
+	directors := make(map[string]int)
+	genres := make(map[string]int)
+	for len(picked) < limit {
+		best, bestValue := -1, math.Inf(-1)
+		for i, r := range recommendations {
+			if used[i] || !withinCaps(r, d, directors, genres) {
+				continue
+			}
+			value := d.Lambda*relevance[i] - (1-d.Lambda)*maxSim[i]
+			if value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+		if best == -1 {
+			break
+		}
+		used[best] = true
+		r := recommendations[best]
+		picked = append(picked, r)
+		directors[r.Movie.DirectorID]++
+		for _, g := range r.Movie.Genres {
+			genres[g.ID]++
+		}
+		for i := range recommendations {
+			if !used[i] {
+				maxSim[i] = math.Max(maxSim[i], FeatureSimilarity(recommendations[i], r))
+			}
+		}
+	}
+	return picked
+}
+
+func withinCaps(r Recommendation, d Diversity, directors, genres map[string]int) bool {
+	if d.MaxPerDirector > 0 && r.Movie.DirectorID != "" && directors[r.Movie.DirectorID] >= d.MaxPerDirector {
+		return false
+	}
+	if d.MaxPerGenre > 0 {
+		for _, g := range r.Movie.Genres {
+			if genres[g.ID] >= d.MaxPerGenre {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// IntraListDiversity is mean pairwise dissimilarity 1 - FeatureSimilarity of list, 0 for less than two movies
+func IntraListDiversity(recommendations []Recommendation) float64 {
+	var sum float64
+	var pairs int
+	for i := range recommendations {
+		for j := i + 1; j < len(recommendations); j++ {
+			sum += 1 - FeatureSimilarity(recommendations[i], recommendations[j])
+			pairs++
+		}
+	}
+	if pairs == 0 {
+		return 0
+	}
+	return sum / float64(pairs)
+}
@@ -0,0 +1,46 @@
+package recommend
+
+import (
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"testing"
+)
+
+func TestRerank(t *testing.T) {
+	scifi := []genre.Genre{{ID: "scifi"}}
+	comedy := []genre.Genre{{ID: "comedy"}}
+	recommendations := []Recommendation{
+		{Movie: movie.Movie{ID: "dune", DirectorID: "villeneuve", Genres: scifi}, Score: 9},
+		{Movie: movie.Movie{ID: "arrival", DirectorID: "villeneuve", Genres: scifi}, Score: 8.9},
+		{Movie: movie.Movie{ID: "blade-runner", DirectorID: "villeneuve", Genres: scifi}, Score: 8.8},
+		{Movie: movie.Movie{ID: "airplane", DirectorID: "abrahams", Genres: comedy}, Score: 8},
+	}
+
+	t.Run("lambda 1 keeps order", func(t *testing.T) {
+		got := Rerank(recommendations, Diversity{Lambda: 1}, 3)
+		assertOrder(t, got, "dune", "arrival", "blade-runner")
+	})
+	t.Run("low lambda promotes different movie", func(t *testing.T) {
+		got := Rerank(recommendations, Diversity{Lambda: 0.5}, 3)
+		assertOrder(t, got, "dune", "airplane", "arrival")
+		if IntraListDiversity(got) <= IntraListDiversity(recommendations[:3]) {
+			t.Errorf("re-ranked list must be more diverse")
+		}
+	})
+	t.Run("director cap", func(t *testing.T) {
+		got := Rerank(recommendations, Diversity{Lambda: 1, MaxPerDirector: 2}, 4)
+		assertOrder(t, got, "dune", "arrival", "airplane")
+	})
+}
+
+func assertOrder(t *testing.T, got []Recommendation, ids ...string) {
+	t.Helper()
+	if len(got) != len(ids) {
+		t.Fatalf("expected %d movies, got %d", len(ids), len(got))
+	}
+	for i, id := range ids {
+		if got[i].Movie.ID != id {
+			t.Errorf("expected %s at %d, got %s", id, i, got[i].Movie.ID)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package recommend
+
+import (
+	"context"
+	"math"
+)
+
+// Metrics of strategy on test set, all values are averaged over evaluated users
+type Metrics struct {
+	Strategy  string  `json:"strategy"`
+	K         int     `json:"k"`
+	Users     int     `json:"users"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	MAP       float64 `json:"map"`
+	NDCG      float64 `json:"ndcg"`
+	// Coverage is share of train catalog appearing in any top-k list
+	Coverage float64 `json:"coverage"`
+	// Novelty is mean self-information -log2(popularity) of recommended movies
+	Novelty float64 `json:"novelty"`
+}
+
+// Evaluate recommends top-k to every test user known in train and compares with relevant test ratings,
+// rating is relevant when its score is at least threshold
+func Evaluate(ctx context.Context, strategy Strategy, train, test *Dataset, k int, threshold float64) (Metrics, error) {
+	m := Metrics{Strategy: strategy.Name(), K: k}
+	recommended := make(map[string]struct{})
+	trainUsers := float64(len(train.Users()))
+	var noveltySum float64
+	var noveltyCount int
+
+	for _, userID := range test.Users() {
+		if len(train.UserRatings(userID)) == 0 {
+			continue
+		}
+		relevant := make(map[string]struct{})
+		for movieID, score := range test.UserRatings(userID) {
+			if score >= threshold {
+				relevant[movieID] = struct{}{}
+			}
+		}
+		if len(relevant) == 0 {
+			continue
+		}
+		candidates, err := strategy.Recommend(ctx, Query{UserID: userID, Limit: k})
+		if err != nil {
+			return Metrics{}, err
+		}
+
+		var hits int
+		var ap, dcg, idcg float64
+		for i, c := range candidates {
+			recommended[c.MovieID] = struct{}{}
+			if pop := len(train.ItemRatings(c.MovieID)); pop > 0 {
+				noveltySum += -math.Log2(float64(pop) / trainUsers)
+				noveltyCount++
+			}
+			if _, ok := relevant[c.MovieID]; ok {
+				hits++
+				ap += float64(hits) / float64(i+1)
+				dcg += 1 / math.Log2(float64(i+2))
+			}
+		}
+		for i := 0; i < len(relevant) && i < k; i++ {
+			idcg += 1 / math.Log2(float64(i+2))
+		}
+
+		m.Users++
+		m.Precision += float64(hits) / float64(k)
+		m.Recall += float64(hits) / float64(len(relevant))
+		m.MAP += ap / math.Min(float64(len(relevant)), float64(k))
+		m.NDCG += dcg / idcg
+	}
+
+	if m.Users > 0 {
+		users := float64(m.Users)
+		m.Precision /= users
+		m.Recall /= users
+		m.MAP /= users
+		m.NDCG /= users
+	}
+	if items := len(train.Items()); items > 0 {
+		m.Coverage = float64(len(recommended)) / float64(items)
+	}
+	if noveltyCount > 0 {
+		m.Novelty = noveltySum / float64(noveltyCount)
+	}
+	return m, nil
+}
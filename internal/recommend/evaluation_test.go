@@ -0,0 +1,64 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"math"
+	"testing"
+)
+
+type fixedStrategy []Candidate
+
+func (f fixedStrategy) Name() string {
+	return "fixed"
+}
+
+func (f fixedStrategy) Recommend(_ context.Context, _ Query) ([]Candidate, error) {
+	return f, nil
+}
+
+func TestEvaluate(t *testing.T) {
+	train := NewDataset([]rating.Rating{
+		{UserID: "u1", MovieID: "a", Score: 8},
+		{UserID: "u2", MovieID: "b", Score: 8},
+		{UserID: "u2", MovieID: "c", Score: 8},
+		{UserID: "u2", MovieID: "d", Score: 8},
+	})
+	test := NewDataset([]rating.Rating{
+		{UserID: "u1", MovieID: "b", Score: 9},
+		{UserID: "u1", MovieID: "d", Score: 7},
+		{UserID: "u1", MovieID: "c", Score: 2},
+	})
+	strategy := fixedStrategy{{MovieID: "b"}, {MovieID: "c"}}
+
+	m, err := Evaluate(context.TODO(), strategy, train, test, 2, 7)
+	if err != nil {
+		t.Fatalf("can't evaluate: %v", err)
+	}
+	expected := Metrics{
+		Strategy:  "fixed",
+		K:         2,
+		Users:     1,
+		Precision: 0.5,
+		Recall:    0.5,
+		MAP:       0.5,
+		NDCG:      1 / (1 + 1/math.Log2(3)),
+		Coverage:  0.5,
+		Novelty:   1,
+	}
+	for name, pair := range map[string][2]float64{
+		"precision": {m.Precision, expected.Precision},
+		"recall":    {m.Recall, expected.Recall},
+		"map":       {m.MAP, expected.MAP},
+		"ndcg":      {m.NDCG, expected.NDCG},
+		"coverage":  {m.Coverage, expected.Coverage},
+		"novelty":   {m.Novelty, expected.Novelty},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-9 {
+			t.Errorf("wrong %s: got %f, expected %f", name, pair[0], pair[1])
+		}
+	}
+	if m.Users != expected.Users {
+		t.Errorf("wrong number of users: %d", m.Users)
+	}
+}
@@ -0,0 +1,212 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/director"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"sort"
+)
+
+// Reasons of recommendation
+const (
+	ReasonSeedMovie    = "seed_movie"
+	ReasonSharedGenres = "shared_genres"
+	ReasonSameDirector = "same_director"
+	ReasonPopular      = "popular"
+)
+
+const (
+	// maxSeeds is how many contributing movies are reported for one recommendation
+	maxSeeds = 3
+	// minSeedWeight drops seeds which barely relate to recommended movie
+	minSeedWeight = 0.1
+)
+
+// Explanation is machine-readable reason of recommendation, only fields of its reason are set
+type Explanation struct {
+	Reason string  `json:"reason" example:"seed_movie"`
+	Weight float64 `json:"weight" example:"0.64"`
+	// Movie is seed movie user liked, picked in onboarding or opened in session
+	Movie    *movie.Movie       `json:"movie,omitempty"`
+	Genres   []genre.Genre      `json:"genres,omitempty"`
+	Director *director.Director `json:"director,omitempty"`
+}
+
+type DirectorLookup interface {
+	GetDirectorByID(ctx context.Context, id string) (director.Director, error)
+}
+
+// ItemSimilarity is implemented by strategies which learn movie-movie similarity from ratings
+type ItemSimilarity interface {
+	Similarity(a, b string) float64
+}
+
+// Explainer finds why movie suits user, independently of strategy which recommended it:
+// seeds are movies user liked or picked in onboarding, collaborative similarity to them is used when
+// strategy provides it, content similarity otherwise
+type Explainer struct {
+	catalog   *Catalog
+	prefs     PreferenceSource
+	directors DirectorLookup
+}
+
+func NewExplainer(catalog *Catalog, prefs PreferenceSource, directors DirectorLookup) *Explainer {
+	return &Explainer{
+		catalog:   catalog,
+		prefs:     prefs,
+		directors: directors,
+	}
+}
+
+// Explain returns explanations of every movie by its id, sim may be nil
+func (e *Explainer) Explain(ctx context.Context, ds *Dataset, sim ItemSimilarity, userID string,
+	movies []movie.Movie) (map[string][]Explanation, error) {
+	prefs, err := e.prefs.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	seeds := make([]movie.Movie, 0)
+	seen := make(map[string]struct{})
+	addSeed := func(movieID string) {
+		if _, ok := seen[movieID]; ok {
+			return
+		}
+		seen[movieID] = struct{}{}
+		if m, ok := e.catalog.Get(movieID); ok {
+			seeds = append(seeds, m)
+		}
+	}
+	for _, movieID := range prefs.MovieIDs {
+		addSeed(movieID)
+	}
+	for movieID, score := range ds.UserRatings(userID) {
+		if score >= likedScore {
+			addSeed(movieID)
+		}
+	}
+	favourite := make(map[string]struct{}, len(prefs.GenreIDs))
+	for _, genreID := range prefs.GenreIDs {
+		favourite[genreID] = struct{}{}
+	}
+	seedDirectors := make(map[string]int)
+	for _, seed := range seeds {
+		for _, g := range seed.Genres {
+			favourite[g.ID] = struct{}{}
+		}
+		if seed.DirectorID != "" {
+			seedDirectors[seed.DirectorID]++
+		}
+	}
+
+	directors := make(map[string]*director.Director)
+	explanations := make(map[string][]Explanation, len(movies))
+	for _, m := range movies {
+		reasons := seedReasons(seeds, sim, m)
+
+		shared := make([]genre.Genre, 0)
+		for _, g := range m.Genres {
+			if _, ok := favourite[g.ID]; ok {
+				shared = append(shared, g)
+			}
+		}
+		if len(shared) > 0 {
+			reasons = append(reasons, Explanation{
+				Reason: ReasonSharedGenres,
+				Weight: float64(len(shared)) / float64(len(m.Genres)),
+				Genres: shared,
+			})
+		}
+
+		if count := seedDirectors[m.DirectorID]; count > 0 {
+			d, err := e.director(ctx, directors, m.DirectorID)
+			if err != nil {
+				return nil, err
+			}
+			if d != nil {
+				reasons = append(reasons, Explanation{
+					Reason:   ReasonSameDirector,
+					Weight:   float64(count) / float64(len(seeds)),
+					Director: d,
+				})
+			}
+		}
+
+		if len(reasons) == 0 {
+			reasons = append(reasons, Explanation{
+				Reason: ReasonPopular,
+				Weight: popularity(ds, m.ID),
+			})
+		}
+		explanations[m.ID] = reasons
+	}
+	return explanations, nil
+}
+
+// seedReasons returns seeds which contributed most to movie
+func seedReasons(seeds []movie.Movie, sim ItemSimilarity, m movie.Movie) []Explanation {
+	reasons := make([]Explanation, 0, maxSeeds)
+	for _, seed := range seeds {
+		if seed.ID == m.ID {
+			continue
+		}
+		var weight float64
+		if sim != nil {
+			weight = sim.Similarity(seed.ID, m.ID)
+		}
+		if weight == 0 {
+			// like in similar movies, content alone relates movies only through genres or director
+			c := ContentSimilarity(seed, m)
+			if len(c.SharedGenres) > 0 || seed.DirectorID == m.DirectorID {
+				weight = c.Score
+			}
+		}
+		if weight < minSeedWeight {
+			continue
+		}
+		seed := seed
+		reasons = append(reasons, Explanation{
+			Reason: ReasonSeedMovie,
+			Weight: weight,
+			Movie:  &seed,
+		})
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasons[i].Weight != reasons[j].Weight {
+			return reasons[i].Weight > reasons[j].Weight
+		}
+		return reasons[i].Movie.ID < reasons[j].Movie.ID
+	})
+	if len(reasons) > maxSeeds {
+		reasons = reasons[:maxSeeds]
+	}
+	return reasons
+}
+
+// director looks director up once per request, deleted director is reported as nil
+func (e *Explainer) director(ctx context.Context, cache map[string]*director.Director, id string) (*director.Director, error) {
+	if d, ok := cache[id]; ok {
+		return d, nil
+	}
+	d, err := e.directors.GetDirectorByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, apperror.ErrEntityNotFound) {
+			cache[id] = nil
+			return nil, nil
+		}
+		return nil, err
+	}
+	cache[id] = &d
+	return &d, nil
+}
+
+// popularity is share of users who rated movie
+func popularity(ds *Dataset, movieID string) float64 {
+	users := len(ds.Users())
+	if users == 0 {
+		return 0
+	}
+	return float64(len(ds.ItemRatings(movieID))) / float64(users)
+}
@@ -0,0 +1,89 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/director"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/onboarding"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"testing"
+)
+
+type staticMovies []movie.Movie
+
+func (s staticMovies) GetAllMovies(context.Context) ([]movie.Movie, error) {
+	return s, nil
+}
+
+type staticPrefs onboarding.Preferences
+
+func (s staticPrefs) GetPreferences(context.Context, string) (onboarding.Preferences, error) {
+	return onboarding.Preferences(s), nil
+}
+
+type staticDirectors map[string]director.Director
+
+func (s staticDirectors) GetDirectorByID(_ context.Context, id string) (director.Director, error) {
+	d, ok := s[id]
+	if !ok {
+		return director.Director{}, apperror.ErrEntityNotFound
+	}
+	return d, nil
+}
+
+func (s staticDirectors) GetAllDirectors(context.Context) ([]director.Director, error) {
+	directors := make([]director.Director, 0, len(s))
+	for _, d := range s {
+		directors = append(directors, d)
+	}
+	return directors, nil
+}
+
+func TestExplainer_Explain(t *testing.T) {
+	scifi := genre.Genre{ID: "scifi", Name: "Sci-Fi"}
+	drama := genre.Genre{ID: "drama", Name: "Drama"}
+	comedy := genre.Genre{ID: "comedy", Name: "Comedy"}
+	dune := movie.Movie{ID: "dune", Duration: 9300, DirectorID: "villeneuve", Genres: []genre.Genre{scifi, drama}}
+	arrival := movie.Movie{ID: "arrival", Duration: 6960, DirectorID: "villeneuve", Genres: []genre.Genre{scifi}}
+	airplane := movie.Movie{ID: "airplane", Duration: 5280, DirectorID: "abrahams", Genres: []genre.Genre{comedy}}
+
+	catalog := NewCatalog(staticMovies{dune, arrival, airplane}, staticDirectors{})
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ds := NewDataset([]rating.Rating{
+		{UserID: "u1", MovieID: "dune", Score: 9},
+		{UserID: "u2", MovieID: "airplane", Score: 6},
+	})
+	explainer := NewExplainer(catalog, staticPrefs{}, staticDirectors{
+		"villeneuve": {ID: "villeneuve", LastName: "Villeneuve"},
+	})
+
+	explanations, err := explainer.Explain(context.Background(), ds, nil, "u1", []movie.Movie{arrival, airplane})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reasons := make(map[string]Explanation)
+	for _, e := range explanations["arrival"] {
+		reasons[e.Reason] = e
+	}
+	if seed, ok := reasons[ReasonSeedMovie]; !ok || seed.Movie.ID != "dune" {
+		t.Errorf("dune must be seed of arrival: %v", explanations["arrival"])
+	}
+	if g, ok := reasons[ReasonSharedGenres]; !ok || len(g.Genres) != 1 || g.Genres[0].ID != "scifi" {
+		t.Errorf("sci-fi must be shared genre of arrival: %v", explanations["arrival"])
+	}
+	if d, ok := reasons[ReasonSameDirector]; !ok || d.Director.LastName != "Villeneuve" {
+		t.Errorf("villeneuve must be director of arrival: %v", explanations["arrival"])
+	}
+
+	if len(explanations["airplane"]) != 1 || explanations["airplane"][0].Reason != ReasonPopular {
+		t.Fatalf("airplane must fall back to popularity: %v", explanations["airplane"])
+	}
+	if w := explanations["airplane"][0].Weight; w != 0.5 {
+		t.Errorf("expected popularity 0.5, got %f", w)
+	}
+}
@@ -0,0 +1,219 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/slate"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// exposureTop is number of directors and countries reported in exposure metrics
+const exposureTop = 10
+
+type ExposureSource interface {
+	GetExposure(ctx context.Context, since time.Time) ([]slate.Exposure, error)
+}
+
+// Fairness configures exposure re-ranking, targets are shares of position weighted exposure
+// of slates served within Window
+type Fairness struct {
+	// MinNonOscarShare is the least share directors without Oscar should get, movies of such directors
+	// are boosted while their share is lower
+	MinNonOscarShare float64 `json:"min_non_oscar_share" example:"0.3"`
+	// MaxDirectorShare and MaxCountryShare cap share of one director and one country, movies over cap
+	// are demoted, 0 means no cap
+	MaxDirectorShare float64 `json:"max_director_share" example:"0.05"`
+	MaxCountryShare  float64 `json:"max_country_share" example:"0.5"`
+	// Strength is the biggest change of score relative to score range of list, 0 disables re-ranking
+	Strength float64       `json:"strength" example:"0.3"`
+	Window   time.Duration `json:"-"`
+}
+
+type ExposureMetrics struct {
+	Targets Fairness `json:"targets"`
+	// Exposure is total exposure of window including slates served since last refresh
+	Exposure      float64               `json:"exposure" example:"1004.2"`
+	NonOscarShare float64               `json:"non_oscar_share" example:"0.27"`
+	TopDirectors  []slate.ExposureShare `json:"top_directors"`
+	TopCountries  []slate.ExposureShare `json:"top_countries"`
+	RefreshedAt   time.Time             `json:"refreshed_at" example:"2024-03-17T12:00:00Z"`
+}
+
+// Exposure tracks cumulative exposure of directors and countries in served slates and re-ranks
+// recommendations toward fairness targets. It is loaded from slates of window on refresh
+// and grows with every served list in between
+type Exposure struct {
+	catalog *Catalog
+	source  ExposureSource
+	logger  *slog.Logger
+	cfg     Fairness
+
+	mu          sync.RWMutex
+	directors   map[string]float64
+	countries   map[string]float64
+	nonOscar    float64
+	total       float64
+	refreshedAt time.Time
+}
+
+func NewExposure(catalog *Catalog, source ExposureSource, logger *slog.Logger, cfg Fairness) *Exposure {
+	return &Exposure{
+		catalog:   catalog,
+		source:    source,
+		logger:    logger,
+		cfg:       cfg,
+		directors: make(map[string]float64),
+		countries: make(map[string]float64),
+	}
+}
+
+func (e *Exposure) Refresh(ctx context.Context) error {
+	exposure, err := e.source.GetExposure(ctx, time.Now().Add(-e.cfg.Window))
+	if err != nil {
+		return err
+	}
+	fresh := NewExposure(e.catalog, e.source, e.logger, e.cfg)
+	for _, ex := range exposure {
+		fresh.add(ex.MovieID, ex.Exposure)
+	}
+	e.mu.Lock()
+	e.directors = fresh.directors
+	e.countries = fresh.countries
+	e.nonOscar = fresh.nonOscar
+	e.total = fresh.total
+	e.refreshedAt = time.Now()
+	e.mu.Unlock()
+	return nil
+}
+
+// Run reloads exposure every interval until ctx is done, so that slates leaving window stop counting
+func (e *Exposure) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Refresh(ctx); err != nil {
+				e.logger.Error("failed to refresh exposure", logging.Err(err))
+			}
+		}
+	}
+}
+
+// add counts exposure of movie, caller holds lock
+func (e *Exposure) add(movieID string, exposure float64) {
+	m, ok := e.catalog.Get(movieID)
+	if !ok {
+		return
+	}
+	e.total += exposure
+	e.directors[m.DirectorID] += exposure
+	d, ok := e.catalog.Director(m.DirectorID)
+	if !ok || !d.HasOscar {
+		e.nonOscar += exposure
+	}
+	if ok && d.Country != "" {
+		e.countries[d.Country] += exposure
+	}
+}
+
+// Record counts exposure of served list
+func (e *Exposure) Record(recommendations []Recommendation) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, rec := range recommendations {
+		e.add(rec.Movie.ID, positionExposure(i+1))
+	}
+}
+
+// positionExposure is exposure of item at position starting from 1, the same as in exposure report
+func positionExposure(position int) float64 {
+	return 1 / math.Log2(float64(position+1))
+}
+
+// Adjust shifts scores of recommendations toward fairness targets by current exposure. Movies of
+// directors without Oscar are boosted while their share is below target, movies of directors and
+// countries over cap are demoted by how far they are over it
+func (e *Exposure) Adjust(recommendations []Recommendation) []Recommendation {
+	if e.cfg.Strength == 0 || len(recommendations) == 0 {
+		return recommendations
+	}
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, r := range recommendations {
+		low, high = math.Min(low, r.Score), math.Max(high, r.Score)
+	}
+	scale := 1.0
+	if high > low {
+		scale = high - low
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.total == 0 {
+		return recommendations
+	}
+	var boost float64
+	if target := e.cfg.MinNonOscarShare; target > 0 && e.nonOscar/e.total < target {
+		boost = (target - e.nonOscar/e.total) / target
+	}
+	for i, r := range recommendations {
+		var adjust float64
+		d, ok := e.catalog.Director(r.Movie.DirectorID)
+		if !ok || !d.HasOscar {
+			adjust += boost
+		}
+		if target := e.cfg.MaxDirectorShare; target > 0 {
+			adjust -= overCap(e.directors[r.Movie.DirectorID]/e.total, target)
+		}
+		if target := e.cfg.MaxCountryShare; target > 0 && ok && d.Country != "" {
+			adjust -= overCap(e.countries[d.Country]/e.total, target)
+		}
+		recommendations[i].Score += e.cfg.Strength * scale * adjust
+	}
+	return recommendations
+}
+
+// overCap is how far share is over cap relative to cap, at most 1
+func overCap(share, target float64) float64 {
+	if share <= target {
+		return 0
+	}
+	return math.Min((share-target)/target, 1)
+}
+
+func (e *Exposure) Metrics() ExposureMetrics {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	m := ExposureMetrics{
+		Targets:     e.cfg,
+		Exposure:    e.total,
+		RefreshedAt: e.refreshedAt,
+	}
+	if e.total > 0 {
+		m.NonOscarShare = e.nonOscar / e.total
+	}
+	m.TopDirectors = e.top(e.directors)
+	m.TopCountries = e.top(e.countries)
+	return m
+}
+
+// top returns groups with the most exposure, caller holds lock
+func (e *Exposure) top(exposure map[string]float64) []slate.ExposureShare {
+	shares := make([]slate.ExposureShare, 0, len(exposure))
+	for key, ex := range exposure {
+		shares = append(shares, slate.ExposureShare{Key: key, Exposure: ex, Share: ex / e.total})
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Exposure != shares[j].Exposure {
+			return shares[i].Exposure > shares[j].Exposure
+		}
+		return shares[i].Key < shares[j].Key
+	})
+	return shares[:min(len(shares), exposureTop)]
+}
@@ -0,0 +1,104 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/slate"
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+)
+
+type staticExposure []slate.Exposure
+
+func (s staticExposure) GetExposure(context.Context, time.Time) ([]slate.Exposure, error) {
+	return s, nil
+}
+
+func TestExposure_Adjust(t *testing.T) {
+	movies := []movie.Movie{
+		{ID: "a", DirectorID: "famous"},
+		{ID: "b", DirectorID: "indie"},
+	}
+	directors := staticDirectors{
+		"famous": {ID: "famous", Country: "USA", HasOscar: true},
+		"indie":  {ID: "indie", Country: "France"},
+	}
+	catalog := NewCatalog(staticMovies(movies), directors)
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	served := staticExposure{{MovieID: "a", Exposure: 9}, {MovieID: "b", Exposure: 1}}
+	recs := func() []Recommendation {
+		return []Recommendation{{Movie: movies[0], Score: 1}, {Movie: movies[1], Score: 0.8}}
+	}
+
+	tests := []struct {
+		name string
+		cfg  Fairness
+		// want are adjusted scores of a and b
+		want [2]float64
+	}{
+		{
+			name: "disabled",
+			cfg:  Fairness{MinNonOscarShare: 0.3, MaxDirectorShare: 0.5},
+			want: [2]float64{1, 0.8},
+		},
+		{
+			name: "non oscar boosted",
+			cfg:  Fairness{MinNonOscarShare: 0.3, Strength: 1},
+			want: [2]float64{1, 0.8 + 0.2*(0.3-0.1)/0.3},
+		},
+		{
+			name: "director over cap demoted",
+			cfg:  Fairness{MaxDirectorShare: 0.5, Strength: 1},
+			want: [2]float64{1 - 0.2*0.8, 0.8},
+		},
+		{
+			name: "country over cap demoted at most by strength",
+			cfg:  Fairness{MaxCountryShare: 0.1, Strength: 0.5},
+			want: [2]float64{1 - 0.5*0.2, 0.8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewExposure(catalog, served, logger, tt.cfg)
+			if err := e.Refresh(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			got := e.Adjust(recs())
+			for i := range got {
+				if math.Abs(got[i].Score-tt.want[i]) > 1e-9 {
+					t.Errorf("score of %s = %f, want %f", got[i].Movie.ID, got[i].Score, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExposure_Record(t *testing.T) {
+	movies := []movie.Movie{{ID: "a", DirectorID: "famous"}, {ID: "b", DirectorID: "indie"}}
+	directors := staticDirectors{
+		"famous": {ID: "famous", Country: "USA", HasOscar: true},
+		"indie":  {ID: "indie", Country: "France"},
+	}
+	catalog := NewCatalog(staticMovies(movies), directors)
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	e := NewExposure(catalog, staticExposure(nil), slog.New(slog.NewTextHandler(io.Discard, nil)), Fairness{})
+	e.Record([]Recommendation{{Movie: movies[0]}, {Movie: movies[1]}})
+
+	m := e.Metrics()
+	// second position has exposure 1/log2(3)
+	second := 1 / math.Log2(3)
+	if math.Abs(m.Exposure-(1+second)) > 1e-9 || math.Abs(m.NonOscarShare-second/(1+second)) > 1e-9 {
+		t.Errorf("unexpected exposure %f and non oscar share %f", m.Exposure, m.NonOscarShare)
+	}
+	if len(m.TopCountries) != 2 || m.TopCountries[0].Key != "USA" || len(m.TopDirectors) != 2 {
+		t.Errorf("USA must lead countries: %+v", m.TopCountries)
+	}
+}
@@ -0,0 +1,400 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/events"
+	"github.com/danyatalent/movie-recommend/internal/exclusion"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"github.com/danyatalent/movie-recommend/internal/trending"
+	"sort"
+	"time"
+)
+
+// Types of feed rows, UI picks row template by type
+const (
+	RowContinueWatching   = "continue_watching"
+	RowRecommended        = "recommended"
+	RowBecauseYouWatched  = "because_you_watched"
+	RowTopInGenre         = "top_in_genre"
+	RowFavouriteDirectors = "favourite_directors"
+	RowTrending           = "trending"
+)
+
+const (
+	// FeedRowSize is the most items row keeps, rows are paginated within it
+	FeedRowSize = 30
+	// feedSeeds is number of recently watched movies getting own row, feedGenres is the same for favourite genres
+	feedSeeds          = 3
+	feedGenres         = 2
+	feedTrendingWindow = "7d"
+)
+
+type ProgressSource interface {
+	GetUserInteractions(ctx context.Context, userID string) ([]events.Interactions, error)
+}
+
+type UserRatingSource interface {
+	GetRatingsByUser(ctx context.Context, userID string) ([]rating.Rating, error)
+}
+
+// CandidateSource gives recommendations of user with exclusions, ratings and diversity already applied
+type CandidateSource interface {
+	Candidates(ctx context.Context, userID string, limit int) ([]Recommendation, error)
+}
+
+type RankingSource interface {
+	Trending(ctx context.Context, window, genre, userID string, limit int) ([]trending.Ranked, error)
+	Top(ctx context.Context, genre, userID string, limit int) ([]trending.Ranked, error)
+}
+
+type FeedItem struct {
+	Movie movie.Movie `json:"movie"`
+	Score float64     `json:"score" example:"0.73"`
+	// Progress is percent of movie watched, set in continue watching row only
+	Progress int `json:"progress,omitempty" example:"40"`
+}
+
+type FeedRow struct {
+	// ID is stable between requests while row has the same source, it is used to page items of one row
+	ID    string `json:"id" example:"because_you_watched:dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+	Type  string `json:"type" example:"because_you_watched"`
+	Title string `json:"title" example:"Because you watched Dune"`
+	// Seed is movie row is built from, Genre is genre of top in genre row
+	Seed       *movie.Movie `json:"seed,omitempty"`
+	Genre      *genre.Genre `json:"genre,omitempty"`
+	Items      []FeedItem   `json:"items"`
+	TotalItems int          `json:"total_items" example:"30"`
+	Page       int          `json:"page" example:"1"`
+}
+
+type Feed struct {
+	UserID    string    `json:"user_id" example:"a9aec972-2c52-441a-8f17-79506cd34366"`
+	Rows      []FeedRow `json:"rows"`
+	TotalRows int       `json:"total_rows" example:"8"`
+	Page      int       `json:"page" example:"1"`
+}
+
+// FeedPage is page of rows or items, Number starts from 1
+type FeedPage struct {
+	Number int
+	Limit  int
+}
+
+func (p FeedPage) bounds(total int) (int, int) {
+	from := min((p.Number-1)*p.Limit, total)
+	return from, min(from+p.Limit, total)
+}
+
+// Feeds assembles home screen of user from recommendation sources. Rows are built in fixed order
+// and a movie is shown only in the first row it gets into, movies user watched or rated are hidden
+// everywhere except continue watching row
+type Feeds struct {
+	candidates CandidateSource
+	catalog    *Catalog
+	exclusions exclusion.Source
+	content    *Content
+	rankings   RankingSource
+	taste      TasteSource
+	progress   ProgressSource
+	ratings    UserRatingSource
+}
+
+func NewFeeds(candidates CandidateSource, catalog *Catalog, exclusions exclusion.Source, content *Content,
+	rankings RankingSource, taste TasteSource, progress ProgressSource, ratings UserRatingSource) *Feeds {
+	return &Feeds{
+		candidates: candidates,
+		catalog:    catalog,
+		exclusions: exclusions,
+		content:    content,
+		rankings:   rankings,
+		taste:      taste,
+		progress:   progress,
+		ratings:    ratings,
+	}
+}
+
+// Feed returns page of rows with first page of items of every row
+func (f *Feeds) Feed(ctx context.Context, userID string, rows, items FeedPage) (Feed, error) {
+	plan, shown, err := f.plan(ctx, userID)
+	if err != nil {
+		return Feed{}, err
+	}
+	all, err := f.build(ctx, plan, shown)
+	if err != nil {
+		return Feed{}, err
+	}
+	from, to := rows.bounds(len(all))
+	feed := Feed{UserID: userID, Rows: make([]FeedRow, 0, to-from), TotalRows: len(all), Page: rows.Number}
+	for _, row := range all[from:to] {
+		feed.Rows = append(feed.Rows, paginate(row, items))
+	}
+	return feed, nil
+}
+
+// Row returns page of items of one row of feed, rows missing in current feed are not found.
+// Only rows up to the requested one are built, earlier rows decide which movies it keeps
+func (f *Feeds) Row(ctx context.Context, userID, rowID string, items FeedPage) (FeedRow, error) {
+	plan, shown, err := f.plan(ctx, userID)
+	if err != nil {
+		return FeedRow{}, err
+	}
+	for i := range plan {
+		if plan[i].ID != rowID {
+			continue
+		}
+		built, err := f.build(ctx, plan[:i+1], shown)
+		if err != nil {
+			return FeedRow{}, err
+		}
+		// requested row is empty once movies of earlier rows are removed
+		if len(built) == 0 || built[len(built)-1].ID != rowID {
+			break
+		}
+		return paginate(built[len(built)-1], items), nil
+	}
+	return FeedRow{}, apperror.ErrEntityNotFound
+}
+
+func paginate(row FeedRow, items FeedPage) FeedRow {
+	from, to := items.bounds(len(row.Items))
+	row.TotalItems = len(row.Items)
+	row.Items = row.Items[from:to]
+	row.Page = items.Number
+	return row
+}
+
+// plannedRow is row of feed before its items are fetched
+type plannedRow struct {
+	FeedRow
+	items func(ctx context.Context) ([]FeedItem, error)
+}
+
+// plan returns rows of user in feed order without items, and movies hidden from the start.
+// Sources of items are read only when row is built
+func (f *Feeds) plan(ctx context.Context, userID string) ([]plannedRow, map[string]struct{}, error) {
+	excluded, err := exclusion.Load(ctx, f.exclusions, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	interactions, err := f.progress.GetUserInteractions(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ratings, err := f.ratings.GetRatingsByUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// watched and rated movies are hidden from the start
+	shown := make(map[string]struct{})
+	// interactions are most recent first
+	watching := make([]FeedItem, 0)
+	seeds := make([]seed, 0)
+	for _, i := range interactions {
+		m, ok := f.catalog.Get(i.MovieID)
+		if !ok {
+			continue
+		}
+		if i.InProgress() && !excluded.Excludes(m) {
+			watching = append(watching, FeedItem{Movie: m, Progress: i.MaxProgress})
+		}
+		if i.Watched() || i.InProgress() {
+			shown[m.ID] = struct{}{}
+		}
+		if i.Watched() {
+			seeds = append(seeds, seed{movie: m, at: i.LastAt})
+		}
+	}
+	for _, rt := range ratings {
+		m, ok := f.catalog.Get(rt.MovieID)
+		if !ok {
+			continue
+		}
+		shown[m.ID] = struct{}{}
+		if rt.Score >= likedScore {
+			seeds = append(seeds, seed{movie: m, at: rt.RatedAt})
+		}
+	}
+	// movies in progress are already hidden, continue watching row must keep them
+	for _, item := range watching {
+		delete(shown, item.Movie.ID)
+	}
+
+	plan := []plannedRow{
+		{
+			FeedRow: FeedRow{ID: RowContinueWatching, Type: RowContinueWatching, Title: "Continue watching"},
+			items: func(context.Context) ([]FeedItem, error) {
+				return watching, nil
+			},
+		},
+		{
+			FeedRow: FeedRow{ID: RowRecommended, Type: RowRecommended, Title: "Recommended for you"},
+			items: func(ctx context.Context) ([]FeedItem, error) {
+				return f.recommended(ctx, userID)
+			},
+		},
+	}
+	for _, s := range recentSeeds(seeds, feedSeeds) {
+		plan = append(plan, plannedRow{
+			FeedRow: FeedRow{
+				ID:    RowBecauseYouWatched + ":" + s.ID,
+				Type:  RowBecauseYouWatched,
+				Title: fmt.Sprintf("Because you watched %s", s.Name),
+				Seed:  &s,
+			},
+			items: func(ctx context.Context) ([]FeedItem, error) {
+				return f.similar(ctx, s.ID, userID)
+			},
+		})
+	}
+
+	profile := f.taste.Profile(userID)
+	for _, ga := range profile.Genres[:min(len(profile.Genres), feedGenres)] {
+		g := ga.Genre
+		plan = append(plan, plannedRow{
+			FeedRow: FeedRow{
+				ID:    RowTopInGenre + ":" + g.ID,
+				Type:  RowTopInGenre,
+				Title: fmt.Sprintf("Top in %s", g.Name),
+				Genre: &g,
+			},
+			items: func(ctx context.Context) ([]FeedItem, error) {
+				top, err := f.rankings.Top(ctx, g.ID, userID, 2*FeedRowSize)
+				return rankedItems(top), err
+			},
+		})
+	}
+
+	plan = append(plan,
+		plannedRow{
+			FeedRow: FeedRow{ID: RowFavouriteDirectors, Type: RowFavouriteDirectors, Title: "From directors you like"},
+			items: func(context.Context) ([]FeedItem, error) {
+				return f.favouriteDirectors(profile, excluded), nil
+			},
+		},
+		plannedRow{
+			FeedRow: FeedRow{ID: RowTrending, Type: RowTrending, Title: "Trending now"},
+			items: func(ctx context.Context) ([]FeedItem, error) {
+				hot, err := f.rankings.Trending(ctx, feedTrendingWindow, "", userID, 2*FeedRowSize)
+				return rankedItems(hot), err
+			},
+		},
+	)
+	return plan, shown, nil
+}
+
+// build fetches items of planned rows in order and returns non-empty ones without duplicates,
+// each with at most FeedRowSize items. Shown movies are hidden and movies of every row are added to them
+func (f *Feeds) build(ctx context.Context, plan []plannedRow, shown map[string]struct{}) ([]FeedRow, error) {
+	rows := make([]FeedRow, 0, len(plan))
+	for _, p := range plan {
+		all, err := p.items(ctx)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]FeedItem, 0, min(len(all), FeedRowSize))
+		for _, item := range all {
+			if len(items) == FeedRowSize {
+				break
+			}
+			if _, ok := shown[item.Movie.ID]; ok {
+				continue
+			}
+			shown[item.Movie.ID] = struct{}{}
+			items = append(items, item)
+		}
+		if len(items) > 0 {
+			row := p.FeedRow
+			row.Items = items
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// recommended takes candidates of user the same way recommendations endpoint does
+func (f *Feeds) recommended(ctx context.Context, userID string) ([]FeedItem, error) {
+	recs, err := f.candidates.Candidates(ctx, userID, 2*FeedRowSize)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]FeedItem, 0, len(recs))
+	for _, rec := range recs {
+		items = append(items, FeedItem{Movie: rec.Movie, Score: rec.Score})
+	}
+	return items, nil
+}
+
+func (f *Feeds) similar(ctx context.Context, movieID, userID string) ([]FeedItem, error) {
+	similar, err := f.content.Similar(ctx, movieID, userID, 2*FeedRowSize)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]FeedItem, 0, len(similar))
+	for _, sm := range similar {
+		items = append(items, FeedItem{Movie: sm.Movie, Score: sm.Score})
+	}
+	return items, nil
+}
+
+// favouriteDirectors lists best rated movies of top directors of taste profile
+func (f *Feeds) favouriteDirectors(profile TasteProfile, excluded exclusion.Set) []FeedItem {
+	affinity := make(map[string]float64, len(profile.Directors))
+	for _, d := range profile.Directors {
+		affinity[d.Director.ID] = d.Affinity
+	}
+	items := make([]FeedItem, 0)
+	for _, m := range f.catalog.All() {
+		if _, ok := affinity[m.DirectorID]; ok && !excluded.Excludes(m) {
+			items = append(items, FeedItem{Movie: m, Score: m.Rating})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i].Movie, items[j].Movie
+		if a.Rating != b.Rating {
+			return a.Rating > b.Rating
+		}
+		return a.ID < b.ID
+	})
+	return items
+}
+
+func rankedItems(ranked []trending.Ranked) []FeedItem {
+	items := make([]FeedItem, 0, len(ranked))
+	for _, rm := range ranked {
+		items = append(items, FeedItem{Movie: rm.Movie, Score: rm.Score})
+	}
+	return items
+}
+
+type seed struct {
+	movie movie.Movie
+	at    time.Time
+}
+
+// recentSeeds returns n distinct movies watched or liked last
+func recentSeeds(seeds []seed, n int) []movie.Movie {
+	sort.Slice(seeds, func(i, j int) bool {
+		if !seeds[i].at.Equal(seeds[j].at) {
+			return seeds[i].at.After(seeds[j].at)
+		}
+		return seeds[i].movie.ID < seeds[j].movie.ID
+	})
+	picked := make(map[string]struct{}, n)
+	movies := make([]movie.Movie, 0, n)
+	for _, s := range seeds {
+		if len(movies) == n {
+			break
+		}
+		if _, ok := picked[s.movie.ID]; ok {
+			continue
+		}
+		picked[s.movie.ID] = struct{}{}
+		movies = append(movies, s.movie)
+	}
+	return movies
+}
@@ -0,0 +1,136 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/director"
+	"github.com/danyatalent/movie-recommend/internal/events"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"github.com/danyatalent/movie-recommend/internal/trending"
+	"testing"
+	"time"
+)
+
+type staticInteractions []events.Interactions
+
+func (s staticInteractions) GetUserInteractions(context.Context, string) ([]events.Interactions, error) {
+	return s, nil
+}
+
+type staticUserRatings []rating.Rating
+
+func (s staticUserRatings) GetRatingsByUser(context.Context, string) ([]rating.Rating, error) {
+	return s, nil
+}
+
+type staticRankings []trending.Ranked
+
+func (s staticRankings) Trending(context.Context, string, string, string, int) ([]trending.Ranked, error) {
+	return s, nil
+}
+
+func (s staticRankings) Top(context.Context, string, string, int) ([]trending.Ranked, error) {
+	return s, nil
+}
+
+// countingRankings counts requests of trending row
+type countingRankings struct {
+	staticRankings
+	trending int
+}
+
+func (c *countingRankings) Trending(ctx context.Context, window, genre, userID string, limit int) ([]trending.Ranked, error) {
+	c.trending++
+	return c.staticRankings.Trending(ctx, window, genre, userID, limit)
+}
+
+func TestFeeds(t *testing.T) {
+	drama := genre.Genre{ID: "drama", Name: "Drama"}
+	movies := make([]movie.Movie, 0)
+	for _, id := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		movies = append(movies, movie.Movie{ID: id, Name: id, DirectorID: "d-" + id, Genres: []genre.Genre{drama}})
+	}
+	ratings := []rating.Rating{
+		{UserID: "u1", MovieID: "b", Score: 9, RatedAt: time.Now()},
+		{UserID: "u2", MovieID: "c", Score: 8},
+		{UserID: "u2", MovieID: "d", Score: 8},
+		{UserID: "u3", MovieID: "c", Score: 8},
+	}
+	service, _ := newTestService(t, movies, nil, ratings, nil)
+	ranked := make([]trending.Ranked, 0, len(movies))
+	for _, m := range movies {
+		ranked = append(ranked, trending.Ranked{Movie: m, Score: 1})
+	}
+	taste := staticTaste{"u1": {
+		UserID:    "u1",
+		Genres:    []GenreAffinity{{Genre: drama, Affinity: 1}},
+		Directors: []DirectorAffinity{{Director: director.Director{ID: "d-g"}, Affinity: 1}},
+	}}
+	interactions := staticInteractions{
+		{UserID: "u1", MovieID: "a", Plays: 1, MaxProgress: 40},
+		{UserID: "u1", MovieID: "b", Plays: 1, Completes: 1},
+	}
+	rankings := &countingRankings{staticRankings: ranked}
+	feeds := NewFeeds(service, service.catalog, staticExclusions(nil), NewContent(service.catalog, staticExclusions(nil)),
+		rankings, taste, interactions, staticUserRatings(ratings[:1]))
+
+	feed, err := feeds.Feed(context.Background(), "u1", FeedPage{Number: 1, Limit: 10}, FeedPage{Number: 1, Limit: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Rows) == 0 || feed.Rows[0].Type != RowContinueWatching {
+		t.Fatalf("feed must start with continue watching: %+v", feed.Rows)
+	}
+	if items := feed.Rows[0].Items; len(items) != 1 || items[0].Movie.ID != "a" || items[0].Progress != 40 {
+		t.Errorf("only a must be continued: %+v", items)
+	}
+	seen := make(map[string]string)
+	for _, row := range feed.Rows {
+		if row.Type == RowBecauseYouWatched && (row.Seed == nil || row.Seed.ID != "b") {
+			t.Errorf("watched b must be the only seed: %+v", row.Seed)
+		}
+		for _, item := range row.Items {
+			if item.Movie.ID == "b" {
+				t.Errorf("watched movie b must be hidden, found in %s", row.ID)
+			}
+			if prev, ok := seen[item.Movie.ID]; ok {
+				t.Errorf("movie %s is in both %s and %s", item.Movie.ID, prev, row.ID)
+			}
+			seen[item.Movie.ID] = row.ID
+		}
+	}
+	// every movie but watched one is somewhere in feed
+	if len(seen) != len(movies)-1 {
+		t.Errorf("expected %d movies in feed, got %v", len(movies)-1, seen)
+	}
+
+	page, err := feeds.Feed(context.Background(), "u1", FeedPage{Number: 2, Limit: 1}, FeedPage{Number: 1, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalRows != len(feed.Rows) || len(page.Rows) != 1 || page.Rows[0].ID != feed.Rows[1].ID {
+		t.Errorf("second page must hold second row: %+v", page)
+	}
+	if row := page.Rows[0]; len(row.Items) != 1 || row.TotalItems != len(feed.Rows[1].Items) {
+		t.Errorf("row must hold one item of %d: %+v", len(feed.Rows[1].Items), row)
+	}
+
+	second := feed.Rows[1]
+	rankings.trending = 0
+	row, err := feeds.Row(context.Background(), "u1", second.ID, FeedPage{Number: 2, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.Items) > 1 && (len(row.Items) != 1 || row.Items[0].Movie.ID != second.Items[1].Movie.ID) {
+		t.Errorf("second page of row must hold its second item: %+v", row)
+	}
+	if rankings.trending != 0 {
+		t.Errorf("rows after requested one must not be built")
+	}
+	if _, err = feeds.Row(context.Background(), "u1", "unknown", FeedPage{Number: 1, Limit: 1}); !errors.Is(err, apperror.ErrEntityNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
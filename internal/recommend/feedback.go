@@ -0,0 +1,86 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+)
+
+// ExplicitSource gives ratings users set themselves
+type ExplicitSource interface {
+	RatingSource
+	GetRatingsByUser(ctx context.Context, userID string) ([]rating.Rating, error)
+}
+
+// ImplicitSource gives preferences inferred from behaviour, expressed on rating scale
+type ImplicitSource interface {
+	GetImplicitRatings(ctx context.Context) ([]rating.Rating, error)
+	GetUserImplicitRatings(ctx context.Context, userID string) ([]rating.Rating, error)
+}
+
+// Feedback merges explicit ratings with implicit signals, explicit score always wins
+// and the strongest implicit signal wins among implicit ones
+type Feedback struct {
+	explicit ExplicitSource
+	implicit []ImplicitSource
+}
+
+func NewFeedback(explicit ExplicitSource, implicit ...ImplicitSource) *Feedback {
+	return &Feedback{
+		explicit: explicit,
+		implicit: implicit,
+	}
+}
+
+func (f *Feedback) GetAllRatings(ctx context.Context) ([]rating.Rating, error) {
+	implicit := make([][]rating.Rating, 0, len(f.implicit))
+	for _, source := range f.implicit {
+		ratings, err := source.GetImplicitRatings(ctx)
+		if err != nil {
+			return nil, err
+		}
+		implicit = append(implicit, ratings)
+	}
+	explicit, err := f.explicit.GetAllRatings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return merge(explicit, implicit), nil
+}
+
+// GetUserRatings merges feedback of one user, it is used to update per user state without full reload
+func (f *Feedback) GetUserRatings(ctx context.Context, userID string) ([]rating.Rating, error) {
+	implicit := make([][]rating.Rating, 0, len(f.implicit))
+	for _, source := range f.implicit {
+		ratings, err := source.GetUserImplicitRatings(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		implicit = append(implicit, ratings)
+	}
+	explicit, err := f.explicit.GetRatingsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return merge(explicit, implicit), nil
+}
+
+func merge(explicit []rating.Rating, implicit [][]rating.Rating) []rating.Rating {
+	type key struct{ userID, movieID string }
+	merged := make(map[key]rating.Rating)
+	for _, ratings := range implicit {
+		for _, rt := range ratings {
+			k := key{rt.UserID, rt.MovieID}
+			if prev, ok := merged[k]; !ok || rt.Score > prev.Score {
+				merged[k] = rt
+			}
+		}
+	}
+	for _, rt := range explicit {
+		merged[key{rt.UserID, rt.MovieID}] = rt
+	}
+	ratings := make([]rating.Rating, 0, len(merged))
+	for _, rt := range merged {
+		ratings = append(ratings, rt)
+	}
+	return ratings
+}
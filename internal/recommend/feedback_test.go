@@ -0,0 +1,108 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"testing"
+)
+
+type staticExplicit struct{ staticRatings }
+
+func (s staticExplicit) GetRatingsByUser(ctx context.Context, userID string) ([]rating.Rating, error) {
+	return s.GetUserRatings(ctx, userID)
+}
+
+type staticImplicit []rating.Rating
+
+func (s staticImplicit) GetImplicitRatings(context.Context) ([]rating.Rating, error) {
+	return s, nil
+}
+
+func (s staticImplicit) GetUserImplicitRatings(ctx context.Context, userID string) ([]rating.Rating, error) {
+	return staticRatings(s).GetUserRatings(ctx, userID)
+}
+
+func scoresOf(ratings []rating.Rating) map[[2]string]int {
+	scores := make(map[[2]string]int, len(ratings))
+	for _, r := range ratings {
+		scores[[2]string{r.UserID, r.MovieID}] = r.Score
+	}
+	return scores
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		explicit []rating.Rating
+		implicit [][]rating.Rating
+		want     map[[2]string]int
+	}{
+		{
+			name:     "explicit only",
+			explicit: []rating.Rating{{UserID: "u1", MovieID: "m1", Score: 7}},
+			want:     map[[2]string]int{{"u1", "m1"}: 7},
+		},
+		{
+			name:     "explicit wins over stronger implicit",
+			explicit: []rating.Rating{{UserID: "u1", MovieID: "m1", Score: 3}},
+			implicit: [][]rating.Rating{{{UserID: "u1", MovieID: "m1", Score: 9}}},
+			want:     map[[2]string]int{{"u1", "m1"}: 3},
+		},
+		{
+			name: "strongest implicit wins among implicit",
+			implicit: [][]rating.Rating{
+				{{UserID: "u1", MovieID: "m1", Score: 6}},
+				{{UserID: "u1", MovieID: "m1", Score: 8}},
+				{{UserID: "u1", MovieID: "m1", Score: 7}},
+			},
+			want: map[[2]string]int{{"u1", "m1"}: 8},
+		},
+		{
+			name:     "different users and movies are kept apart",
+			explicit: []rating.Rating{{UserID: "u1", MovieID: "m1", Score: 5}},
+			implicit: [][]rating.Rating{{
+				{UserID: "u2", MovieID: "m1", Score: 8},
+				{UserID: "u1", MovieID: "m2", Score: 6},
+			}},
+			want: map[[2]string]int{{"u1", "m1"}: 5, {"u2", "m1"}: 8, {"u1", "m2"}: 6},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := merge(tt.explicit, tt.implicit)
+			if len(merged) != len(tt.want) {
+				t.Fatalf("expected %d ratings, got %v", len(tt.want), merged)
+			}
+			for k, score := range scoresOf(merged) {
+				if want, ok := tt.want[k]; !ok || want != score {
+					t.Errorf("%v: score = %d, want %d", k, score, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFeedback_GetUserRatings(t *testing.T) {
+	f := NewFeedback(
+		staticExplicit{staticRatings{
+			{UserID: "u1", MovieID: "m1", Score: 4},
+			{UserID: "u2", MovieID: "m1", Score: 9},
+		}},
+		staticImplicit{{UserID: "u1", MovieID: "m1", Score: 8}, {UserID: "u1", MovieID: "m2", Score: 6}},
+		staticImplicit{{UserID: "u1", MovieID: "m2", Score: 7}, {UserID: "u2", MovieID: "m3", Score: 6}},
+	)
+	ratings, err := f.GetUserRatings(context.Background(), "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[[2]string]int{{"u1", "m1"}: 4, {"u1", "m2"}: 7}
+	got := scoresOf(ratings)
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for k, score := range want {
+		if got[k] != score {
+			t.Errorf("%v: score = %d, want %d", k, got[k], score)
+		}
+	}
+}
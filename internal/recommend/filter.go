@@ -0,0 +1,65 @@
+package recommend
+
+import (
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"strings"
+)
+
+// Filter narrows recommendations down to movies matching every constraint, zero values don't filter
+type Filter struct {
+	// Genres are matched by id or name, movie needs any of them
+	Genres []string `json:"genres,omitempty" example:"Comedy,Drama"`
+	// MaxDuration is in seconds
+	MaxDuration      int      `json:"max_duration,omitempty" example:"7200"`
+	MinRating        float64  `json:"min_rating,omitempty" example:"7"`
+	ExcludeDirectors []string `json:"exclude_directors,omitempty"`
+	// HasOscarDirector keeps movies of directors with or without oscar, nil keeps both
+	HasOscarDirector *bool `json:"has_oscar_director,omitempty" example:"true"`
+	// MinYear and MaxYear bound year of release, movies with unknown year don't pass them
+	MinYear int `json:"min_year,omitempty" example:"1990"`
+	MaxYear int `json:"max_year,omitempty" example:"2010"`
+}
+
+func (f Filter) Empty() bool {
+	return len(f.Genres) == 0 && f.MaxDuration == 0 && f.MinRating == 0 &&
+		len(f.ExcludeDirectors) == 0 && f.HasOscarDirector == nil && f.MinYear == 0 && f.MaxYear == 0
+}
+
+// allows tells whether movie passes filter, catalog resolves director of movie
+func (f Filter) allows(catalog *Catalog, m movie.Movie) bool {
+	if f.MaxDuration > 0 && m.Duration > f.MaxDuration {
+		return false
+	}
+	if m.Rating < f.MinRating {
+		return false
+	}
+	if (f.MinYear > 0 || f.MaxYear > 0) && m.Year == 0 {
+		return false
+	}
+	if m.Year < f.MinYear || f.MaxYear > 0 && m.Year > f.MaxYear {
+		return false
+	}
+	for _, id := range f.ExcludeDirectors {
+		if m.DirectorID == id {
+			return false
+		}
+	}
+	if f.HasOscarDirector != nil {
+		d, ok := catalog.Director(m.DirectorID)
+		if !ok || d.HasOscar != *f.HasOscarDirector {
+			return false
+		}
+	}
+	return len(f.Genres) == 0 || hasGenre(m, f.Genres)
+}
+
+func hasGenre(m movie.Movie, genres []string) bool {
+	for _, g := range m.Genres {
+		for _, want := range genres {
+			if g.ID == want || strings.EqualFold(g.Name, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/exclusion"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"slices"
+	"sort"
+)
+
+// Aggregations of member preferences into group score
+const (
+	AggregateAverage      = "average"
+	AggregateLeastMisery  = "least_misery"
+	AggregateMostPleasure = "most_pleasure"
+)
+
+// GroupRequest of movies to watch together, MaxDuration caps total duration of list, 0 means no cap,
+// movies of unknown duration are skipped under cap
+type GroupRequest struct {
+	UserIDs     []string
+	Limit       int
+	Aggregation string
+	MaxDuration int
+}
+
+type GroupRecommendation struct {
+	Recommendation
+	// MemberScores is preference of every member in [0, 1], 0 when movie isn't among member's candidates
+	MemberScores map[string]float64 `json:"member_scores"`
+}
+
+type GroupResult struct {
+	Recommendations []GroupRecommendation `json:"recommendations"`
+	Aggregation     string                `json:"aggregation" example:"least_misery"`
+	TotalDuration   int                   `json:"total_duration" example:"14400"`
+}
+
+// Group ranks movies for members watching together. Candidates of every member are turned into
+// rank based preferences so that lists of different strategies are comparable, then preferences
+// are aggregated. Movies any member rated or isn't interested in are skipped
+func (s *Service) Group(ctx context.Context, req GroupRequest) (GroupResult, error) {
+	preferences := make(map[string]map[string]float64, len(req.UserIDs))
+	// movie excluded by one member is skipped even if only other members have it among candidates
+	excluded := make([]exclusion.Set, 0, len(req.UserIDs))
+	skip := make(map[string]struct{})
+	for _, userID := range req.UserIDs {
+		set, err := exclusion.Load(ctx, s.exclusions, userID)
+		if err != nil {
+			return GroupResult{}, err
+		}
+		excluded = append(excluded, set)
+		entry, _, err := s.entry(ctx, userID)
+		if err != nil {
+			return GroupResult{}, err
+		}
+		prefs := make(map[string]float64, len(entry.Candidates))
+		for i, c := range entry.Candidates {
+			prefs[c.MovieID] = 1 - float64(i)/float64(len(entry.Candidates))
+		}
+		preferences[userID] = prefs
+		rated, err := s.rated(ctx, userID)
+		if err != nil {
+			return GroupResult{}, err
+		}
+		for movieID := range rated {
+			skip[movieID] = struct{}{}
+		}
+	}
+
+	type scored struct {
+		GroupRecommendation
+		average float64
+	}
+	pool := make([]scored, 0)
+	seen := make(map[string]struct{})
+	for _, prefs := range preferences {
+		for movieID := range prefs {
+			if _, ok := seen[movieID]; ok {
+				continue
+			}
+			seen[movieID] = struct{}{}
+			if _, ok := skip[movieID]; ok {
+				continue
+			}
+			m, ok := s.catalog.Get(movieID)
+			if !ok || excludedByAny(excluded, m) {
+				continue
+			}
+			members := make(map[string]float64, len(preferences))
+			values := make([]float64, 0, len(preferences))
+			for userID, p := range preferences {
+				members[userID] = p[movieID]
+				values = append(values, p[movieID])
+			}
+			pool = append(pool, scored{
+				GroupRecommendation: GroupRecommendation{
+					Recommendation: Recommendation{Movie: m, Score: aggregate(req.Aggregation, values)},
+					MemberScores:   members,
+				},
+				average: aggregate(AggregateAverage, values),
+			})
+		}
+	}
+	// average breaks ties which are common for least misery and most pleasure
+	sort.Slice(pool, func(i, j int) bool {
+		if pool[i].Score != pool[j].Score {
+			return pool[i].Score > pool[j].Score
+		}
+		if pool[i].average != pool[j].average {
+			return pool[i].average > pool[j].average
+		}
+		return pool[i].Movie.ID < pool[j].Movie.ID
+	})
+
+	result := GroupResult{
+		Recommendations: make([]GroupRecommendation, 0, req.Limit),
+		Aggregation:     req.Aggregation,
+	}
+	for _, p := range pool {
+		if len(result.Recommendations) == req.Limit {
+			break
+		}
+		// shorter movies further down can still fit into remaining time, movies of unknown
+		// duration can't be promised to fit
+		if req.MaxDuration > 0 && (p.Movie.Duration == 0 || result.TotalDuration+p.Movie.Duration > req.MaxDuration) {
+			continue
+		}
+		result.TotalDuration += p.Movie.Duration
+		result.Recommendations = append(result.Recommendations, p.GroupRecommendation)
+	}
+	return result, nil
+}
+
+func excludedByAny(sets []exclusion.Set, m movie.Movie) bool {
+	for _, set := range sets {
+		if set.Excludes(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func aggregate(aggregation string, values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	switch aggregation {
+	case AggregateLeastMisery:
+		return slices.Min(values)
+	case AggregateMostPleasure:
+		return slices.Max(values)
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
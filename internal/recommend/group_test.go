@@ -0,0 +1,120 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/exclusion"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"testing"
+)
+
+type exclusionsByUser map[string][]exclusion.Exclusion
+
+func (s exclusionsByUser) GetExclusions(_ context.Context, userID string) ([]exclusion.Exclusion, error) {
+	return s[userID], nil
+}
+
+func TestAggregate(t *testing.T) {
+	values := []float64{0.2, 0.8, 0.5}
+	tests := []struct {
+		aggregation string
+		want        float64
+	}{
+		{AggregateAverage, 0.5},
+		{AggregateLeastMisery, 0.2},
+		{AggregateMostPleasure, 0.8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.aggregation, func(t *testing.T) {
+			if got := aggregate(tt.aggregation, values); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_Group(t *testing.T) {
+	movies := []movie.Movie{
+		{ID: "a", Duration: 3600},
+		{ID: "b", Duration: 3600},
+		{ID: "c", Duration: 7200},
+		{ID: "d", DirectorID: "d1", Duration: 3600},
+		{ID: "f", Duration: 600},
+		// duration is unknown
+		{ID: "g"},
+	}
+	ratings := []rating.Rating{
+		{UserID: "u1", MovieID: "a", Score: 8},
+		{UserID: "u2", MovieID: "a", Score: 8},
+		{UserID: "u2", MovieID: "b", Score: 8},
+		{UserID: "u3", MovieID: "c", Score: 8},
+		{UserID: "u3", MovieID: "d", Score: 8},
+		{UserID: "u3", MovieID: "f", Score: 8},
+		{UserID: "u4", MovieID: "c", Score: 8},
+		{UserID: "u4", MovieID: "d", Score: 8},
+		{UserID: "u4", MovieID: "g", Score: 8},
+	}
+	service, _ := newTestService(t, movies, nil, ratings, []exclusion.Exclusion{{Kind: exclusion.KindDirector, TargetID: "d1"}})
+
+	tests := []struct {
+		name        string
+		maxDuration int
+		want        []string
+	}{
+		// a and b are rated by one of members, d is excluded
+		{"no cap", 0, []string{"c", "f", "g"}},
+		{"f doesn't fit after c", 7500, []string{"c"}},
+		{"c doesn't fit", 7000, []string{"f"}},
+		{"g of unknown duration is skipped", 20000, []string{"c", "f"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.Group(context.Background(), GroupRequest{
+				UserIDs:     []string{"u1", "u2"},
+				Limit:       5,
+				Aggregation: AggregateLeastMisery,
+				MaxDuration: tt.maxDuration,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(result.Recommendations))
+			for _, rec := range result.Recommendations {
+				got = append(got, rec.Movie.ID)
+				if len(rec.MemberScores) != 2 {
+					t.Errorf("expected scores of both members: %v", rec.MemberScores)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestService_GroupExcludedByOtherMember(t *testing.T) {
+	movies := []movie.Movie{{ID: "a"}, {ID: "d", DirectorID: "d1"}, {ID: "f"}}
+	ratings := []rating.Rating{{UserID: "u1", MovieID: "a", Score: 8}, {UserID: "u2", MovieID: "a", Score: 8}}
+	service, cache := newTestService(t, movies, nil, ratings, nil)
+	service.exclusions = exclusionsByUser{"u2": {{Kind: exclusion.KindDirector, TargetID: "d1"}}}
+	// only u1 has d among candidates, it is excluded by u2
+	cache.Set("u1", Entry{Candidates: []Candidate{{MovieID: "d", Score: 1}, {MovieID: "f", Score: 0.5}}})
+	cache.Set("u2", Entry{Candidates: []Candidate{{MovieID: "f", Score: 1}}})
+
+	result, err := service.Group(context.Background(), GroupRequest{
+		UserIDs:     []string{"u1", "u2"},
+		Limit:       5,
+		Aggregation: AggregateMostPleasure,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Recommendations) != 1 || result.Recommendations[0].Movie.ID != "f" {
+		t.Errorf("expected only f, d is excluded by u2: %v", result.Recommendations)
+	}
+}
@@ -0,0 +1,224 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+)
+
+const (
+	// hybridPool is how many candidates per requested movie every component contributes
+	hybridPool = 3
+	// hybridSeeds caps liked movies content component compares candidates with
+	hybridSeeds = 50
+
+	// content component mixes similarity to liked movies with match of taste profile
+	hybridSeedsWeight = 0.6
+	hybridTasteWeight = 0.4
+)
+
+type TasteSource interface {
+	Profile(userID string) TasteProfile
+}
+
+// HybridWeights of components, they are normalized to sum up to 1
+type HybridWeights struct {
+	Collaborative float64 `json:"collaborative" example:"0.6"`
+	Content       float64 `json:"content" example:"0.25"`
+	Popularity    float64 `json:"popularity" example:"0.15"`
+}
+
+// Components are scores of hybrid components normalized to [0, 1] and weights they were blended with,
+// score of component which didn't propose movie is 0
+type Components struct {
+	Collaborative float64       `json:"collaborative" example:"0.8"`
+	Content       float64       `json:"content" example:"0.4"`
+	Popularity    float64       `json:"popularity" example:"0.1"`
+	Weights       HybridWeights `json:"weights"`
+}
+
+// Hybrid blends collaborative, content and popularity scores. Weights move from newUser weights
+// to heavyUser weights as user rates up to heavyRatings movies. Content score uses taste profile
+// of user as features. Collaborative and popularity strategies are fitted by service as they are
+// router variants too
+type Hybrid struct {
+	catalog       *Catalog
+	taste         TasteSource
+	collaborative Strategy
+	popularity    Strategy
+	newUser       HybridWeights
+	heavyUser     HybridWeights
+	heavyRatings  int
+
+	mu sync.RWMutex
+	ds *Dataset
+}
+
+func NewHybrid(catalog *Catalog, taste TasteSource, collaborative, popularity Strategy,
+	newUser, heavyUser HybridWeights, heavyRatings int) *Hybrid {
+	return &Hybrid{
+		catalog:       catalog,
+		taste:         taste,
+		collaborative: collaborative,
+		popularity:    popularity,
+		newUser:       newUser,
+		heavyUser:     heavyUser,
+		heavyRatings:  heavyRatings,
+	}
+}
+
+func (h *Hybrid) Name() string {
+	return "hybrid"
+}
+
+func (h *Hybrid) Fit(ds *Dataset) error {
+	h.mu.Lock()
+	h.ds = ds
+	h.mu.Unlock()
+	return nil
+}
+
+// Weights returns normalized weights for user with given number of ratings
+func (h *Hybrid) Weights(ratings int) HybridWeights {
+	t := 1.0
+	if h.heavyRatings > 0 {
+		t = math.Min(float64(ratings)/float64(h.heavyRatings), 1)
+	}
+	w := HybridWeights{
+		Collaborative: (1-t)*h.newUser.Collaborative + t*h.heavyUser.Collaborative,
+		Content:       (1-t)*h.newUser.Content + t*h.heavyUser.Content,
+		Popularity:    (1-t)*h.newUser.Popularity + t*h.heavyUser.Popularity,
+	}
+	if sum := w.Collaborative + w.Content + w.Popularity; sum > 0 {
+		w.Collaborative /= sum
+		w.Content /= sum
+		w.Popularity /= sum
+	}
+	return w
+}
+
+// Recommend blends components proposing candidates, every candidate carries its components
+func (h *Hybrid) Recommend(ctx context.Context, q Query) ([]Candidate, error) {
+	h.mu.RLock()
+	ds := h.ds
+	h.mu.RUnlock()
+	if ds == nil {
+		return nil, ErrNotFitted
+	}
+	w := h.Weights(len(ds.UserRatings(q.UserID)))
+	pool := Query{UserID: q.UserID, Limit: q.Limit * hybridPool, Allow: q.Allow}
+
+	components := make(map[string]*Components)
+	component := func(movieID string) *Components {
+		c, ok := components[movieID]
+		if !ok {
+			c = &Components{Weights: w}
+			components[movieID] = c
+		}
+		return c
+	}
+	if w.Collaborative > 0 {
+		candidates, err := h.collaborative.Recommend(ctx, pool)
+		// factor model may be not trained yet, other components still serve
+		if err != nil && !errors.Is(err, ErrNotFitted) {
+			return nil, err
+		}
+		for movieID, score := range normalize(candidates) {
+			component(movieID).Collaborative = score
+		}
+	}
+	if w.Popularity > 0 {
+		candidates, err := h.popularity.Recommend(ctx, pool)
+		if err != nil && !errors.Is(err, ErrNotFitted) {
+			return nil, err
+		}
+		for movieID, score := range normalize(candidates) {
+			component(movieID).Popularity = score
+		}
+	}
+	if w.Content > 0 {
+		for movieID, score := range normalize(h.content(ds, pool)) {
+			component(movieID).Content = score
+		}
+	}
+
+	candidates := make([]Candidate, 0, len(components))
+	for movieID, c := range components {
+		candidates = append(candidates, Candidate{
+			MovieID:    movieID,
+			Score:      w.Collaborative*c.Collaborative + w.Content*c.Content + w.Popularity*c.Popularity,
+			Components: c,
+		})
+	}
+	sortCandidates(candidates)
+	if len(candidates) > q.Limit {
+		candidates = candidates[:q.Limit]
+	}
+	return candidates, nil
+}
+
+// content scores unrated movies by the highest content similarity to movies user liked
+// and by match of user's taste profile
+func (h *Hybrid) content(ds *Dataset, q Query) []Candidate {
+	rated := ds.UserRatings(q.UserID)
+	liked := make([]string, 0)
+	for movieID, score := range rated {
+		if score >= likedScore {
+			liked = append(liked, movieID)
+		}
+	}
+	sort.Slice(liked, func(i, j int) bool {
+		if rated[liked[i]] != rated[liked[j]] {
+			return rated[liked[i]] > rated[liked[j]]
+		}
+		return liked[i] < liked[j]
+	})
+	if len(liked) > hybridSeeds {
+		liked = liked[:hybridSeeds]
+	}
+	profile := h.taste.Profile(q.UserID)
+	if len(liked) == 0 && len(profile.Genres) == 0 {
+		return nil
+	}
+
+	scores := make(map[string]float64)
+	for _, m := range h.catalog.All() {
+		if _, ok := rated[m.ID]; ok || !q.allowed(m.ID) {
+			continue
+		}
+		var best float64
+		for _, seedID := range liked {
+			seed, ok := h.catalog.Get(seedID)
+			if !ok {
+				continue
+			}
+			best = math.Max(best, ContentSimilarity(seed, m).Score)
+		}
+		if score := hybridSeedsWeight*best + hybridTasteWeight*profile.Match(m); score > 0 {
+			scores[m.ID] = score
+		}
+	}
+	return rank(scores, q.Limit)
+}
+
+// normalize maps scores of candidates to [0, 1] by min-max, single candidate gets 1
+func normalize(candidates []Candidate) map[string]float64 {
+	normalized := make(map[string]float64, len(candidates))
+	if len(candidates) == 0 {
+		return normalized
+	}
+	lo, hi := candidates[0].Score, candidates[0].Score
+	for _, c := range candidates {
+		lo, hi = math.Min(lo, c.Score), math.Max(hi, c.Score)
+	}
+	for _, c := range candidates {
+		if hi == lo {
+			normalized[c.MovieID] = 1
+			continue
+		}
+		normalized[c.MovieID] = (c.Score - lo) / (hi - lo)
+	}
+	return normalized
+}
@@ -0,0 +1,122 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"math"
+	"testing"
+)
+
+type staticTaste map[string]TasteProfile
+
+func (s staticTaste) Profile(userID string) TasteProfile {
+	return s[userID]
+}
+
+func TestHybrid_Weights(t *testing.T) {
+	h := NewHybrid(nil, nil, nil, nil,
+		HybridWeights{Collaborative: 0, Content: 1, Popularity: 3},
+		HybridWeights{Collaborative: 8, Content: 2, Popularity: 0},
+		10)
+	tests := []struct {
+		ratings int
+		want    HybridWeights
+	}{
+		{0, HybridWeights{Collaborative: 0, Content: 0.25, Popularity: 0.75}},
+		{5, HybridWeights{Collaborative: 4.0 / 7, Content: 1.5 / 7, Popularity: 1.5 / 7}},
+		{10, HybridWeights{Collaborative: 0.8, Content: 0.2, Popularity: 0}},
+		{50, HybridWeights{Collaborative: 0.8, Content: 0.2, Popularity: 0}},
+	}
+	for _, tt := range tests {
+		got := h.Weights(tt.ratings)
+		if math.Abs(got.Collaborative-tt.want.Collaborative) > 1e-9 ||
+			math.Abs(got.Content-tt.want.Content) > 1e-9 ||
+			math.Abs(got.Popularity-tt.want.Popularity) > 1e-9 {
+			t.Errorf("%d ratings: got %+v, want %+v", tt.ratings, got, tt.want)
+		}
+	}
+}
+
+func TestHybrid_Recommend(t *testing.T) {
+	drama := []genre.Genre{{ID: "drama"}}
+	comedy := []genre.Genre{{ID: "comedy"}}
+	movies := []movie.Movie{
+		{ID: "liked", DirectorID: "d1", Genres: drama},
+		{ID: "similar", DirectorID: "d1", Genres: drama},
+		{ID: "popular", DirectorID: "d2", Genres: comedy},
+	}
+	catalog := NewCatalog(staticMovies(movies), staticDirectors{})
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ds := NewDataset([]rating.Rating{
+		{UserID: "u1", MovieID: "liked", Score: 9},
+		{UserID: "u2", MovieID: "popular", Score: 6},
+		{UserID: "u3", MovieID: "popular", Score: 6},
+	})
+	popular := NewPopular()
+	itemCF := NewItemCF(10)
+	h := NewHybrid(catalog, staticTaste{}, itemCF, popular,
+		HybridWeights{Content: 0.1, Popularity: 0.9},
+		HybridWeights{Content: 0.9, Popularity: 0.1},
+		1)
+	for _, s := range []Trainable{popular, itemCF, h} {
+		if err := s.Fit(ds); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		userID string
+		want   string
+	}{
+		// u1 is heavy user, content similarity to liked movie wins
+		{"u1", "similar"},
+		// unknown user has no history, popularity wins
+		{"u4", "popular"},
+	}
+	for _, tt := range tests {
+		candidates, err := h.Recommend(context.Background(), Query{UserID: tt.userID, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(candidates) == 0 || candidates[0].MovieID != tt.want {
+			t.Errorf("%s: expected %s first, got %+v", tt.userID, tt.want, candidates)
+			continue
+		}
+		if c := candidates[0].Components; c == nil || c.Weights != h.Weights(len(ds.UserRatings(tt.userID))) {
+			t.Errorf("%s: expected components with weights of user, got %+v", tt.userID, c)
+		}
+	}
+}
+
+func TestRegistry_RegisterHybrid(t *testing.T) {
+	movies := []movie.Movie{
+		{ID: "m1", Genres: []genre.Genre{{ID: "drama"}}},
+		{ID: "m2", Genres: []genre.Genre{{ID: "comedy"}}},
+	}
+	catalog := NewCatalog(staticMovies(movies), staticDirectors{})
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	r := DefaultRegistry(nil, 10, ALSParams{})
+	r.RegisterHybrid(catalog, staticTaste{}, 10,
+		HybridWeights{Popularity: 1}, HybridWeights{Collaborative: 1}, 1)
+	h, ok := r.New("hybrid")
+	if !ok {
+		t.Fatalf("hybrid must be registered, got %v", r.Names())
+	}
+	// components are fitted by hybrid, popularity serves user without history
+	if err := h.Fit(NewDataset([]rating.Rating{{UserID: "u1", MovieID: "m2", Score: 8}})); err != nil {
+		t.Fatal(err)
+	}
+	candidates, err := h.Recommend(context.Background(), Query{UserID: "u2", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 || candidates[0].MovieID != "m2" {
+		t.Errorf("expected m2, got %+v", candidates)
+	}
+}
@@ -0,0 +1,133 @@
+package recommend
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+)
+
+// itemCFShrinkage is added to total similarity of neighbours predicting a movie, so that movies
+// reached through few weak neighbours stay close to user mean
+const itemCFShrinkage = 1.0
+
+// ItemCF is item-based collaborative filtering with adjusted cosine similarity
+type ItemCF struct {
+	neighbours int
+
+	mu  sync.RWMutex
+	ds  *Dataset
+	sim map[string]map[string]float64
+}
+
+func NewItemCF(neighbours int) *ItemCF {
+	return &ItemCF{neighbours: neighbours}
+}
+
+func (c *ItemCF) Name() string {
+	return "item-cf"
+}
+
+// Fit builds item-item similarity matrix and keeps only top neighbours of every item
+func (c *ItemCF) Fit(ds *Dataset) error {
+	dot := make(map[string]map[string]float64)
+	norm := make(map[string]float64)
+	for _, userID := range ds.Users() {
+		mean := ds.UserMean(userID)
+		centered := make(map[string]float64)
+		for movieID, score := range ds.UserRatings(userID) {
+			centered[movieID] = score - mean
+			norm[movieID] += (score - mean) * (score - mean)
+		}
+		for i, ri := range centered {
+			if ri == 0 {
+				continue
+			}
+			for j, rj := range centered {
+				if i == j || rj == 0 {
+					continue
+				}
+				if dot[i] == nil {
+					dot[i] = make(map[string]float64)
+				}
+				dot[i][j] += ri * rj
+			}
+		}
+	}
+
+	sim := make(map[string]map[string]float64, len(dot))
+	for i, row := range dot {
+		neighbours := make(map[string]float64)
+		for j, d := range row {
+			s := d / (math.Sqrt(norm[i]) * math.Sqrt(norm[j]))
+			if s > 0 {
+				neighbours[j] = s
+			}
+		}
+		sim[i] = topNeighbours(neighbours, c.neighbours)
+	}
+
+	c.mu.Lock()
+	c.ds = ds
+	c.sim = sim
+	c.mu.Unlock()
+	return nil
+}
+
+// Similarity returns similarity of two movies, 0 if they are not neighbours
+func (c *ItemCF) Similarity(a, b string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sim[a][b]
+}
+
+// Recommend predicts user scores of unseen movies as weighted deviation from user mean shrunk by support
+func (c *ItemCF) Recommend(_ context.Context, q Query) ([]Candidate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.ds == nil {
+		return nil, ErrNotFitted
+	}
+	rated := c.ds.UserRatings(q.UserID)
+	mean := c.ds.UserMean(q.UserID)
+
+	weighted := make(map[string]float64)
+	total := make(map[string]float64)
+	for j, score := range rated {
+		for i, s := range c.sim[j] {
+			if _, ok := rated[i]; ok || !q.allowed(i) {
+				continue
+			}
+			weighted[i] += s * (score - mean)
+			total[i] += s
+		}
+	}
+
+	scores := make(map[string]float64, len(total))
+	for i, t := range total {
+		scores[i] = mean + weighted[i]/(t+itemCFShrinkage)
+	}
+	// well connected movies win ties
+	return rankSupported(scores, total, q.Limit), nil
+}
+
+func topNeighbours(neighbours map[string]float64, k int) map[string]float64 {
+	if k <= 0 || len(neighbours) <= k {
+		return neighbours
+	}
+	ids := make([]string, 0, len(neighbours))
+	for id := range neighbours {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if neighbours[ids[i]] != neighbours[ids[j]] {
+			return neighbours[ids[i]] > neighbours[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	top := make(map[string]float64, k)
+	for _, id := range ids[:k] {
+		top[id] = neighbours[id]
+	}
+	return top
+}
@@ -0,0 +1,92 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"math"
+	"testing"
+)
+
+func testRatings() []rating.Rating {
+	return []rating.Rating{
+		{UserID: "u1", MovieID: "dune", Score: 9},
+		{UserID: "u1", MovieID: "interstellar", Score: 10},
+		{UserID: "u1", MovieID: "notebook", Score: 2},
+		{UserID: "u2", MovieID: "dune", Score: 8},
+		{UserID: "u2", MovieID: "interstellar", Score: 9},
+		{UserID: "u2", MovieID: "arrival", Score: 9},
+		{UserID: "u2", MovieID: "notebook", Score: 3},
+		{UserID: "u3", MovieID: "notebook", Score: 9},
+		{UserID: "u3", MovieID: "titanic", Score: 10},
+		{UserID: "u3", MovieID: "dune", Score: 3},
+		{UserID: "u4", MovieID: "dune", Score: 10},
+	}
+}
+
+func TestItemCF_Recommend(t *testing.T) {
+	cf := NewItemCF(10)
+	if err := cf.Fit(NewDataset(testRatings())); err != nil {
+		t.Fatalf("can't fit: %v", err)
+	}
+	if cf.Similarity("dune", "interstellar") <= 0 {
+		t.Errorf("dune and interstellar must be similar")
+	}
+	if cf.Similarity("dune", "notebook") > 0 {
+		t.Errorf("dune and notebook must not be similar")
+	}
+
+	candidates, err := cf.Recommend(context.TODO(), Query{UserID: "u1", Limit: 10})
+	if err != nil {
+		t.Fatalf("can't recommend: %v", err)
+	}
+	if len(candidates) == 0 || candidates[0].MovieID != "arrival" {
+		t.Errorf("expected arrival first, got %v", candidates)
+	}
+	for _, c := range candidates {
+		if _, ok := map[string]bool{"dune": true, "interstellar": true, "notebook": true}[c.MovieID]; ok {
+			t.Errorf("rated movie %s must be excluded", c.MovieID)
+		}
+	}
+}
+
+func TestItemCF_NotFitted(t *testing.T) {
+	if _, err := NewItemCF(10).Recommend(context.TODO(), Query{UserID: "u1"}); err != ErrNotFitted {
+		t.Errorf("expected ErrNotFitted, got %v", err)
+	}
+}
+
+func TestTopNeighbours(t *testing.T) {
+	neighbours := map[string]float64{"d": 0.5, "c": 0.5, "b": 0.5, "a": 0.9}
+	for i := 0; i < 20; i++ {
+		top := topNeighbours(neighbours, 2)
+		if _, ok := top["b"]; len(top) != 2 || top["a"] == 0 || !ok {
+			t.Fatalf("expected a and b kept, got %v", top)
+		}
+	}
+}
+
+func TestRankSupported(t *testing.T) {
+	scores := map[string]float64{"a": 7, "b": 7, "c": 8}
+	got := rankSupported(scores, map[string]float64{"a": 0.1, "b": 0.9}, 0)
+	if len(got) != 3 || got[0].MovieID != "c" || got[1].MovieID != "b" || got[1].Score != 7 {
+		t.Errorf("expected c then better supported b with unchanged score, got %v", got)
+	}
+}
+
+func TestItemCF_Shrinkage(t *testing.T) {
+	cf := NewItemCF(10)
+	cf.ds = NewDataset([]rating.Rating{{UserID: "u1", MovieID: "a", Score: 10}, {UserID: "u1", MovieID: "b", Score: 4}})
+	// weak is reached through one weak neighbour, full deviation would put it on top
+	cf.sim = map[string]map[string]float64{"a": {"weak": 0.1, "strong": 0.9}}
+
+	candidates, err := cf.Recommend(context.TODO(), Query{UserID: "u1", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 2 || candidates[0].MovieID != "strong" {
+		t.Fatalf("expected strong first, got %v", candidates)
+	}
+	if want := 7 + 0.1*3/(0.1+itemCFShrinkage); math.Abs(candidates[1].Score-want) > 1e-9 {
+		t.Errorf("weak score = %f, want %f", candidates[1].Score, want)
+	}
+}
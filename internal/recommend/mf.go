@@ -0,0 +1,129 @@
+package recommend
+
+import (
+	"context"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ModelStore gives access to factor models trained by cmd/train
+type ModelStore interface {
+	GetActiveModelVersion(ctx context.Context) (string, error)
+	LoadModel(ctx context.Context, version string) (*FactorModel, error)
+}
+
+// MF serves factor model trained offline, model is swapped atomically when newer version is activated
+type MF struct {
+	logger *slog.Logger
+	model  atomic.Pointer[FactorModel]
+
+	mu sync.RWMutex
+	ds *Dataset
+}
+
+func NewMF(logger *slog.Logger) *MF {
+	return &MF{logger: logger}
+}
+
+func (m *MF) Name() string {
+	return "als"
+}
+
+// Fit does not retrain factors, it only keeps dataset to fold in new users and exclude seen movies
+func (m *MF) Fit(ds *Dataset) error {
+	m.mu.Lock()
+	m.ds = ds
+	m.mu.Unlock()
+	return nil
+}
+
+// Model returns currently served model, nil if none is loaded
+func (m *MF) Model() *FactorModel {
+	return m.model.Load()
+}
+
+func (m *MF) Swap(model *FactorModel) {
+	m.model.Store(model)
+}
+
+func (m *MF) Recommend(_ context.Context, q Query) ([]Candidate, error) {
+	model := m.model.Load()
+	m.mu.RLock()
+	ds := m.ds
+	m.mu.RUnlock()
+	if model == nil || ds == nil {
+		return nil, ErrNotFitted
+	}
+	rated := ds.UserRatings(q.UserID)
+	userVec, ok := model.Users[q.UserID]
+	if !ok {
+		if len(rated) == 0 {
+			return []Candidate{}, nil
+		}
+		userVec = model.FoldIn(rated)
+	}
+	scores := make(map[string]float64, len(model.Items))
+	for movieID := range model.Items {
+		if _, ok := rated[movieID]; ok || !q.allowed(movieID) {
+			continue
+		}
+		scores[movieID], _ = model.Predict(userVec, movieID)
+	}
+	return rank(scores, q.Limit), nil
+}
+
+// Load loads active model version if it differs from served one
+func (m *MF) Load(ctx context.Context, store ModelStore) error {
+	version, err := store.GetActiveModelVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if current := m.model.Load(); current != nil && current.Version == version {
+		return nil
+	}
+	model, err := store.LoadModel(ctx, version)
+	if err != nil {
+		return err
+	}
+	m.Swap(model)
+	m.logger.Info("factor model loaded",
+		slog.String("version", model.Version),
+		slog.Int("users", len(model.Users)),
+		slog.Int("items", len(model.Items)),
+	)
+	return nil
+}
+
+// Watch polls store every interval and hot-swaps model on new version or rollback
+func (m *MF) Watch(ctx context.Context, store ModelStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Load(ctx, store); err != nil {
+				m.logger.Error("failed to load factor model", logging.Err(err))
+			}
+		}
+	}
+}
+
+// ALS trains factor model in process on every Fit, it is used for offline evaluation
+type ALS struct {
+	*MF
+	params ALSParams
+}
+
+func NewALS(logger *slog.Logger, params ALSParams) *ALS {
+	return &ALS{MF: NewMF(logger), params: params}
+}
+
+func (a *ALS) Fit(ds *Dataset) error {
+	a.Swap(TrainALS(ds, a.params))
+	return a.MF.Fit(ds)
+}
@@ -0,0 +1,87 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"time"
+)
+
+var ErrNotFitted = errors.New("recommender is not fitted")
+
+// Query describes what recommendations are requested for
+type Query struct {
+	UserID string
+	Limit  int
+	// Allow filters candidates while they are generated so that filtered movies don't eat the limit,
+	// nil allows everything
+	Allow func(movieID string) bool
+}
+
+func (q Query) allowed(movieID string) bool {
+	return q.Allow == nil || q.Allow(movieID)
+}
+
+// Candidate is a movie scored by strategy before it is hydrated from catalog
+type Candidate struct {
+	MovieID string
+	Score   float64
+	// Components are set by hybrid strategy only
+	Components *Components
+}
+
+type Recommendation struct {
+	Movie        movie.Movie   `json:"movie"`
+	Score        float64       `json:"score" example:"8.4"`
+	Explanations []Explanation `json:"explanations"`
+	// Exploration marks movie picked by bandit, its score is sampled click probability
+	Exploration bool `json:"exploration" example:"false"`
+	// Components are scores hybrid strategy blended, returned in debug mode only
+	Components *Components `json:"components,omitempty"`
+}
+
+// Request of recommendations list, Diversity is usually service defaults with per request overrides
+type Request struct {
+	UserID    string
+	Limit     int
+	Diversity Diversity
+	Filter    Filter
+	// Debug returns components of hybrid scores
+	Debug bool
+}
+
+// Result is final recommendations list with metadata describing it
+type Result struct {
+	// RecommendationID identifies stored slate, feedback on its movies refers to it
+	RecommendationID string           `json:"recommendation_id" example:"5c0e2b0f-5a43-4d0c-9a0e-31a1f1c7e1a4"`
+	Recommendations  []Recommendation `json:"recommendations"`
+	Meta             Meta             `json:"meta"`
+}
+
+type Meta struct {
+	// Strategy is strategy which actually served list, e.g. cold start for users with little history
+	Strategy string `json:"strategy" example:"item-cf"`
+	// Experiment and Variant are set when user takes part in running experiment
+	Experiment string `json:"experiment,omitempty" example:"als-vs-item-cf"`
+	Variant    string `json:"variant,omitempty" example:"treatment"`
+	// Cached tells list was served from precomputed candidates, ComputedAt is when they were computed
+	Cached     bool      `json:"cached" example:"true"`
+	ComputedAt time.Time `json:"computed_at" example:"2024-03-17T12:00:00Z"`
+	// Diversity is re-ranking configuration list was built with
+	Diversity Diversity `json:"diversity"`
+	// Filter is constraints every movie of list matches
+	Filter             Filter  `json:"filter"`
+	IntraListDiversity float64 `json:"intra_list_diversity" example:"0.62"`
+}
+
+// Strategy produces ranked candidates for user
+type Strategy interface {
+	Name() string
+	Recommend(ctx context.Context, q Query) ([]Candidate, error)
+}
+
+// Trainable strategies are rebuilt from fresh dataset on every refresh
+type Trainable interface {
+	Strategy
+	Fit(ds *Dataset) error
+}
@@ -0,0 +1,50 @@
+package recommend
+
+import (
+	"context"
+	"sync"
+)
+
+// Popular ranks movies by number of ratings, it is a baseline and fallback for users without history
+type Popular struct {
+	mu     sync.RWMutex
+	ds     *Dataset
+	scores map[string]float64
+}
+
+func NewPopular() *Popular {
+	return &Popular{}
+}
+
+func (p *Popular) Name() string {
+	return "popular"
+}
+
+func (p *Popular) Fit(ds *Dataset) error {
+	scores := make(map[string]float64)
+	for _, movieID := range ds.Items() {
+		scores[movieID] = float64(len(ds.ItemRatings(movieID)))
+	}
+	p.mu.Lock()
+	p.ds = ds
+	p.scores = scores
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *Popular) Recommend(_ context.Context, q Query) ([]Candidate, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.ds == nil {
+		return nil, ErrNotFitted
+	}
+	rated := p.ds.UserRatings(q.UserID)
+	scores := make(map[string]float64, len(p.scores))
+	for movieID, score := range p.scores {
+		if _, ok := rated[movieID]; ok || !q.allowed(movieID) {
+			continue
+		}
+		scores[movieID] = score
+	}
+	return rank(scores, q.Limit), nil
+}
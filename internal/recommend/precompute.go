@@ -0,0 +1,131 @@
+package recommend
+
+import (
+	"context"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"log/slog"
+	"time"
+)
+
+// activityLag covers events written by batcher after they occurred
+const activityLag = time.Minute
+
+// Activity is time of last rating, event, watchlist or onboarding change of user
+type Activity struct {
+	UserID string
+	At     time.Time
+}
+
+type ActivitySource interface {
+	GetActiveUsers(ctx context.Context, since time.Time) ([]Activity, error)
+}
+
+// Precomputer keeps cache of service warm. Users with new activity are recomputed as soon as
+// service is refitted on ratings including it, other entries are recomputed when older than maxAge
+// so that model updates reach users who stay inactive. Entries not served within maxIdle are evicted
+type Precomputer struct {
+	service  *Service
+	cache    *Cache
+	activity ActivitySource
+	logger   *slog.Logger
+	maxAge   time.Duration
+	maxIdle  time.Duration
+	window   time.Duration
+
+	since   time.Time
+	pending map[string]time.Time
+}
+
+// NewPrecomputer warms cache for users active within window before start
+func NewPrecomputer(service *Service, cache *Cache, activity ActivitySource, logger *slog.Logger,
+	maxAge, maxIdle, window time.Duration) *Precomputer {
+	return &Precomputer{
+		service:  service,
+		cache:    cache,
+		activity: activity,
+		logger:   logger,
+		maxAge:   maxAge,
+		maxIdle:  maxIdle,
+		window:   window,
+		pending:  make(map[string]time.Time),
+	}
+}
+
+// Run recomputes users every interval until ctx is done
+func (p *Precomputer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Tick(ctx); err != nil {
+			p.logger.Error("failed to precompute recommendations", logging.Err(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick runs one incremental pass, users failed to precompute are logged and retried on next tick
+func (p *Precomputer) Tick(ctx context.Context) error {
+	now := time.Now()
+	if p.since.IsZero() {
+		p.since = now.Add(-p.window)
+	}
+	activity, err := p.activity.GetActiveUsers(ctx, p.since)
+	if err != nil {
+		return err
+	}
+	p.since = now.Add(-activityLag)
+	for _, a := range activity {
+		if a.At.After(p.pending[a.UserID]) {
+			p.pending[a.UserID] = a.At
+		}
+	}
+
+	start := time.Now()
+	fittedAt := p.service.FittedAt()
+	var active, stale, failed int
+	for userID, at := range p.pending {
+		if !fittedAt.After(at) {
+			continue
+		}
+		if !p.precompute(ctx, userID) {
+			failed++
+			continue
+		}
+		delete(p.pending, userID)
+		active++
+	}
+	// idle users are dropped before stale entries are recomputed, nobody would read them
+	evicted := p.cache.RemoveUnusedSince(now.Add(-p.maxIdle))
+	for _, userID := range p.cache.ComputedBefore(now.Add(-p.maxAge)) {
+		if !p.precompute(ctx, userID) {
+			failed++
+			continue
+		}
+		stale++
+	}
+	if active > 0 || stale > 0 || evicted > 0 || failed > 0 {
+		p.logger.Info("recommendations precomputed",
+			slog.Int("active", active),
+			slog.Int("stale", stale),
+			slog.Int("failed", failed),
+			slog.Int("evicted", evicted),
+			slog.Int("waiting", len(p.pending)),
+			slog.Int("cached", p.cache.Len()),
+			slog.Duration("took", time.Since(start)),
+		)
+	}
+	return nil
+}
+
+// precompute caches recommendations of user, failure of one user must not block others
+func (p *Precomputer) precompute(ctx context.Context, userID string) bool {
+	if _, err := p.service.Precompute(ctx, userID); err != nil {
+		p.logger.Error("failed to precompute recommendations", slog.String("user_id", userID), logging.Err(err))
+		return false
+	}
+	return true
+}
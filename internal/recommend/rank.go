@@ -0,0 +1,41 @@
+package recommend
+
+import (
+	"sort"
+)
+
+// rank sorts scores descending and cuts them to limit, limit <= 0 means no cut
+func rank(scores map[string]float64, limit int) []Candidate {
+	return rankSupported(scores, nil, limit)
+}
+
+// rankSupported is rank where movie with more support wins tie of scores, then movie id decides
+func rankSupported(scores, support map[string]float64, limit int) []Candidate {
+	candidates := make([]Candidate, 0, len(scores))
+	for id, score := range scores {
+		candidates = append(candidates, Candidate{MovieID: id, Score: score})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if support[a.MovieID] != support[b.MovieID] {
+			return support[a.MovieID] > support[b.MovieID]
+		}
+		return a.MovieID < b.MovieID
+	})
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+func sortCandidates(candidates []Candidate) {
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].MovieID < candidates[j].MovieID
+	})
+}
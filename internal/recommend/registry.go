@@ -0,0 +1,75 @@
+package recommend
+
+import (
+	"log/slog"
+	"sort"
+)
+
+// Registry holds named strategies which can be trained from dataset, strategies which need
+// more than dataset are registered separately
+type Registry struct {
+	factories map[string]func() Trainable
+}
+
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]func() Trainable)}
+}
+
+func (r *Registry) Register(name string, factory func() Trainable) {
+	r.factories[name] = factory
+}
+
+// New creates fresh instance of strategy, false if it is not registered
+func (r *Registry) New(name string) (Trainable, bool) {
+	factory, ok := r.factories[name]
+	if !ok {
+		return nil, false
+	}
+	return factory(), true
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultRegistry registers every strategy available in the package
+func DefaultRegistry(logger *slog.Logger, neighbours int, als ALSParams) *Registry {
+	r := NewRegistry()
+	r.Register("popular", func() Trainable { return NewPopular() })
+	r.Register("item-cf", func() Trainable { return NewItemCF(neighbours) })
+	r.Register("als", func() Trainable { return NewALS(logger, als) })
+	return r
+}
+
+// RegisterHybrid registers hybrid over catalog and taste profiles, which dataset alone doesn't give.
+// Its collaborative and popularity components are fitted together with it
+func (r *Registry) RegisterHybrid(catalog *Catalog, taste TasteSource, neighbours int,
+	newUser, heavyUser HybridWeights, heavyRatings int) {
+	r.Register("hybrid", func() Trainable {
+		itemCF, popular := NewItemCF(neighbours), NewPopular()
+		return &trainedHybrid{
+			Hybrid:     NewHybrid(catalog, taste, itemCF, popular, newUser, heavyUser, heavyRatings),
+			components: []Trainable{itemCF, popular},
+		}
+	})
+}
+
+// trainedHybrid fits components on its own, in service they are fitted as router variants
+type trainedHybrid struct {
+	*Hybrid
+	components []Trainable
+}
+
+func (h *trainedHybrid) Fit(ds *Dataset) error {
+	for _, c := range h.components {
+		if err := c.Fit(ds); err != nil {
+			return err
+		}
+	}
+	return h.Hybrid.Fit(ds)
+}
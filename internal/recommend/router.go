@@ -0,0 +1,61 @@
+package recommend
+
+import (
+	"fmt"
+	"github.com/danyatalent/movie-recommend/internal/experiment"
+)
+
+type Assigner interface {
+	Assign(userID string) (experiment.Assignment, bool)
+	// Strategies returns strategy names variants are served by
+	Strategies() []string
+}
+
+// Router picks strategy serving user, variant of running experiment overrides default strategy
+type Router struct {
+	def         Strategy
+	strategies  map[string]Strategy
+	experiments Assigner
+}
+
+// NewRouter fails when experiment variant refers to strategy missing among def and variants
+func NewRouter(def Strategy, experiments Assigner, variants ...Strategy) (*Router, error) {
+	r := &Router{
+		def:         def,
+		strategies:  map[string]Strategy{def.Name(): def},
+		experiments: experiments,
+	}
+	for _, s := range variants {
+		r.strategies[s.Name()] = s
+	}
+	for _, name := range experiments.Strategies() {
+		if _, ok := r.strategies[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown strategy %s", experiment.ErrInvalidExperiment, name)
+		}
+	}
+	return r, nil
+}
+
+// Route returns strategy of user and experiment assignment, false when user is in no experiment
+func (r *Router) Route(userID string) (Strategy, experiment.Assignment, bool) {
+	a, ok := r.experiments.Assign(userID)
+	if !ok {
+		return r.def, experiment.Assignment{}, false
+	}
+	return r.strategies[a.Strategy], a, true
+}
+
+// Get returns strategy by name
+func (r *Router) Get(name string) (Strategy, bool) {
+	s, ok := r.strategies[name]
+	return s, ok
+}
+
+// All returns every strategy router can pick
+func (r *Router) All() []Strategy {
+	all := make([]Strategy, 0, len(r.strategies))
+	for _, s := range r.strategies {
+		all = append(all, s)
+	}
+	return all
+}
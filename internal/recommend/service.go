@@ -0,0 +1,428 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/events"
+	"github.com/danyatalent/movie-recommend/internal/exclusion"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"github.com/danyatalent/movie-recommend/internal/slate"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type RatingSource interface {
+	GetAllRatings(ctx context.Context) ([]rating.Rating, error)
+}
+
+// FeedbackSource gives all ratings strategies are fitted on and current ratings of one user,
+// movies user rated after fitting are hidden at serving time
+type FeedbackSource interface {
+	RatingSource
+	GetUserRatings(ctx context.Context, userID string) ([]rating.Rating, error)
+}
+
+type SlateSaver interface {
+	SaveSlate(ctx context.Context, s *slate.Slate) error
+}
+
+// ImpressionLogger records movies shown to users, it must not block
+type ImpressionLogger interface {
+	Add(events []events.Event) error
+}
+
+// Service runs strategy picked by router and turns its candidates into movies from catalog,
+// users with less than minRatings ratings are served by cold start strategy
+type Service struct {
+	logger      *slog.Logger
+	catalog     *Catalog
+	ratings     FeedbackSource
+	router      *Router
+	coldStart   Strategy
+	exclusions  exclusion.Source
+	explainer   *Explainer
+	impressions ImpressionLogger
+	slates      SlateSaver
+	cache       *Cache
+	bandit      *Bandit
+	exposure    *Exposure
+	diversity   Diversity
+	minRatings  int
+
+	mu sync.RWMutex
+	ds *Dataset
+	// fittedAt is when ratings strategies are fitted on were read
+	fittedAt time.Time
+}
+
+func NewService(logger *slog.Logger, catalog *Catalog, ratings FeedbackSource, router *Router, coldStart Strategy,
+	exclusions exclusion.Source, explainer *Explainer, impressions ImpressionLogger, slates SlateSaver,
+	cache *Cache, bandit *Bandit, exposure *Exposure, diversity Diversity, minRatings int) *Service {
+	return &Service{
+		logger:      logger,
+		catalog:     catalog,
+		ratings:     ratings,
+		router:      router,
+		coldStart:   coldStart,
+		exclusions:  exclusions,
+		explainer:   explainer,
+		impressions: impressions,
+		slates:      slates,
+		cache:       cache,
+		bandit:      bandit,
+		exposure:    exposure,
+		diversity:   diversity,
+		minRatings:  minRatings,
+		ds:          NewDataset(nil),
+	}
+}
+
+// Refresh reloads catalog and ratings and refits trainable strategies
+func (s *Service) Refresh(ctx context.Context) error {
+	if err := s.catalog.Refresh(ctx); err != nil {
+		return err
+	}
+	readAt := time.Now()
+	ratings, err := s.ratings.GetAllRatings(ctx)
+	if err != nil {
+		return err
+	}
+	ds := NewDataset(ratings)
+	for _, strategy := range append(s.router.All(), s.coldStart) {
+		t, ok := strategy.(Trainable)
+		if !ok {
+			continue
+		}
+		start := time.Now()
+		if err = t.Fit(ds); err != nil {
+			return err
+		}
+		s.logger.Info("recommender fitted",
+			slog.String("strategy", t.Name()),
+			slog.Int("ratings", len(ratings)),
+			slog.Duration("took", time.Since(start)),
+		)
+	}
+	s.mu.Lock()
+	s.ds = ds
+	s.fittedAt = readAt
+	s.mu.Unlock()
+	return nil
+}
+
+// FittedAt returns time of ratings snapshot strategies are fitted on,
+// activity after it isn't reflected in recommendations yet
+func (s *Service) FittedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fittedAt
+}
+
+// Run refreshes service every interval until ctx is done
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				s.logger.Error("failed to refresh recommender", logging.Err(err))
+			}
+		}
+	}
+}
+
+// DefaultDiversity is re-ranking configuration used unless request overrides it
+func (s *Service) DefaultDiversity() Diversity {
+	return s.diversity
+}
+
+// Precompute computes candidates of user and caches them, exclusions are left to serving time
+// so that changing them doesn't need recomputation
+func (s *Service) Precompute(ctx context.Context, userID string) (Entry, error) {
+	e, err := s.generate(ctx, s.cacheQuery(userID))
+	if err != nil {
+		return Entry{}, err
+	}
+	s.cache.Set(userID, e)
+	return e, nil
+}
+
+// cacheQuery asks for as many candidates as cache keeps, only movies missing in catalog are skipped
+func (s *Service) cacheQuery(userID string) Query {
+	return Query{UserID: userID, Limit: s.cache.size, Allow: s.allowFunc(nil, nil, Filter{})}
+}
+
+// generate runs strategy routed to user, cold start strategy serves users with little history
+func (s *Service) generate(ctx context.Context, q Query) (Entry, error) {
+	s.mu.RLock()
+	ds := s.ds
+	s.mu.RUnlock()
+
+	strategy, assignment, _ := s.router.Route(q.UserID)
+	if len(ds.UserRatings(q.UserID)) < s.minRatings {
+		strategy = s.coldStart
+	}
+	candidates, err := strategy.Recommend(ctx, q)
+	// factor model is not fitted until cmd/train saves its first version
+	if err != nil && !errors.Is(err, ErrNotFitted) {
+		return Entry{}, err
+	}
+	// primary strategy may know nothing about user yet, e.g. factor model trained before first rating
+	if len(candidates) == 0 && strategy != s.coldStart {
+		strategy = s.coldStart
+		if candidates, err = strategy.Recommend(ctx, q); err != nil {
+			return Entry{}, err
+		}
+	}
+	return Entry{
+		Candidates: candidates,
+		Strategy:   strategy.Name(),
+		Assignment: assignment,
+		ComputedAt: time.Now(),
+	}, nil
+}
+
+// entry returns cached candidates of user and true, computing them on a miss. Only users with fitted
+// ratings are cached on a miss so that unknown ids can't fill cache, users with other activity
+// are cached by precomputer
+func (s *Service) entry(ctx context.Context, userID string) (Entry, bool, error) {
+	if e, ok := s.cache.Get(userID); ok {
+		return e, true, nil
+	}
+	s.mu.RLock()
+	known := len(s.ds.UserRatings(userID)) > 0
+	s.mu.RUnlock()
+	if !known {
+		e, err := s.generate(ctx, s.cacheQuery(userID))
+		return e, false, err
+	}
+	e, err := s.Precompute(ctx, userID)
+	return e, false, err
+}
+
+// rated returns movies user has feedback on now, it includes ratings set after strategies were fitted
+func (s *Service) rated(ctx context.Context, userID string) (map[string]struct{}, error) {
+	ratings, err := s.ratings.GetUserRatings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	rated := make(map[string]struct{}, len(ratings))
+	for _, r := range ratings {
+		rated[r.MovieID] = struct{}{}
+	}
+	return rated, nil
+}
+
+// Recommend serves candidates from cache, computing them on a miss, then re-ranks them for exposure
+// fairness and diversity, gives bandit its exploration slots and explains final list. Filtered lists are
+// generated with filter applied by strategy, cached candidates may have too few matching movies to fill the page.
+// Served list is stored as slate, counted as exposure and its movies are logged as impressions tagged
+// with experiment of user
+func (s *Service) Recommend(ctx context.Context, req Request) (Result, error) {
+	userID := req.UserID
+	excluded, err := exclusion.Load(ctx, s.exclusions, userID)
+	if err != nil {
+		return Result{}, err
+	}
+	rated, err := s.rated(ctx, userID)
+	if err != nil {
+		return Result{}, err
+	}
+	// exclusions and ratings may have been added after candidates were computed
+	allow := s.allowFunc(excluded, rated, req.Filter)
+	var (
+		entry  Entry
+		cached bool
+	)
+	if req.Filter.Empty() {
+		entry, cached, err = s.entry(ctx, userID)
+	} else {
+		entry, err = s.generate(ctx, Query{UserID: userID, Limit: req.Limit * candidatesPerSlot, Allow: allow})
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	s.mu.RLock()
+	ds := s.ds
+	s.mu.RUnlock()
+
+	candidates := make([]Candidate, 0, req.Limit*candidatesPerSlot)
+	for _, c := range entry.Candidates {
+		if len(candidates) == req.Limit*candidatesPerSlot {
+			break
+		}
+		if allow(c.MovieID) {
+			candidates = append(candidates, c)
+		}
+	}
+	strategy := s.coldStart
+	if entry.Strategy != s.coldStart.Name() {
+		strategy, _ = s.router.Get(entry.Strategy)
+	}
+	recommendations := Rerank(s.exposure.Adjust(s.hydrate(candidates, req.Debug)), req.Diversity, req.Limit)
+	recommendations = s.bandit.Explore(recommendations, allow, req.Limit)
+	movies := make([]movie.Movie, 0, len(recommendations))
+	for _, rec := range recommendations {
+		if !rec.Exploration {
+			movies = append(movies, rec.Movie)
+		}
+	}
+	explanations, err := s.explainer.Explain(ctx, ds, similarity(strategy), userID, movies)
+	if err != nil {
+		return Result{}, err
+	}
+	for i := range recommendations {
+		if !recommendations[i].Exploration {
+			recommendations[i].Explanations = explanations[recommendations[i].Movie.ID]
+		}
+	}
+	meta := Meta{
+		Strategy:           entry.Strategy,
+		Experiment:         entry.Assignment.Experiment,
+		Variant:            entry.Assignment.Variant,
+		Cached:             cached,
+		ComputedAt:         entry.ComputedAt,
+		Diversity:          req.Diversity,
+		Filter:             req.Filter,
+		IntraListDiversity: IntraListDiversity(recommendations),
+	}
+	s.logImpressions(userID, recommendations, meta)
+	s.exposure.Record(recommendations)
+	return Result{
+		RecommendationID: s.saveSlate(ctx, userID, recommendations, meta),
+		Recommendations:  recommendations,
+		Meta:             meta,
+	}, nil
+}
+
+// Candidates returns up to limit cached candidates of user re-ranked by default diversity, movies user
+// rated or isn't interested in are skipped. Unlike Recommend it explores nothing and logs nothing
+func (s *Service) Candidates(ctx context.Context, userID string, limit int) ([]Recommendation, error) {
+	excluded, err := exclusion.Load(ctx, s.exclusions, userID)
+	if err != nil {
+		return nil, err
+	}
+	rated, err := s.rated(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	entry, _, err := s.entry(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	allow := s.allowFunc(excluded, rated, Filter{})
+	candidates := make([]Candidate, 0, limit*candidatesPerSlot)
+	for _, c := range entry.Candidates {
+		if len(candidates) == limit*candidatesPerSlot {
+			break
+		}
+		if allow(c.MovieID) {
+			candidates = append(candidates, c)
+		}
+	}
+	return Rerank(s.hydrate(candidates, false), s.diversity, limit), nil
+}
+
+// saveSlate returns id of stored slate, failing to store it leaves list without id but still served
+func (s *Service) saveSlate(ctx context.Context, userID string, recommendations []Recommendation, meta Meta) string {
+	sl := slate.Slate{
+		UserID:     userID,
+		Strategy:   meta.Strategy,
+		Experiment: meta.Experiment,
+		Variant:    meta.Variant,
+		Items:      make([]slate.Item, 0, len(recommendations)),
+	}
+	for i, rec := range recommendations {
+		sl.Items = append(sl.Items, slate.Item{
+			MovieID:     rec.Movie.ID,
+			Position:    i + 1,
+			Score:       rec.Score,
+			Exploration: rec.Exploration,
+		})
+	}
+	if err := s.slates.SaveSlate(ctx, &sl); err != nil {
+		s.logger.Warn("failed to save slate", logging.Err(err))
+		return ""
+	}
+	return sl.ID
+}
+
+// logImpressions doesn't fail request, losing impressions under load is acceptable
+func (s *Service) logImpressions(userID string, recommendations []Recommendation, meta Meta) {
+	now := time.Now()
+	impressions := make([]events.Event, 0, len(recommendations))
+	for _, rec := range recommendations {
+		impressions = append(impressions, events.Event{
+			UserID:     userID,
+			MovieID:    rec.Movie.ID,
+			Type:       events.TypeImpression,
+			Experiment: meta.Experiment,
+			Variant:    meta.Variant,
+			OccurredAt: now,
+		})
+	}
+	if err := s.impressions.Add(impressions); err != nil {
+		s.logger.Warn("failed to log impressions", logging.Err(err))
+	}
+}
+
+// Explain tells why movie suits user, movie doesn't have to be among current recommendations
+func (s *Service) Explain(ctx context.Context, userID, movieID string) (movie.Movie, []Explanation, error) {
+	m, ok := s.catalog.Get(movieID)
+	if !ok {
+		return movie.Movie{}, nil, apperror.ErrEntityNotFound
+	}
+	s.mu.RLock()
+	ds := s.ds
+	s.mu.RUnlock()
+	strategy, _, _ := s.router.Route(userID)
+	explanations, err := s.explainer.Explain(ctx, ds, similarity(strategy), userID, []movie.Movie{m})
+	if err != nil {
+		return movie.Movie{}, nil, err
+	}
+	return m, explanations[m.ID], nil
+}
+
+// similarity returns learned movie similarity of strategy, nil if it has none
+func similarity(strategy Strategy) ItemSimilarity {
+	if sim, ok := strategy.(ItemSimilarity); ok {
+		return sim
+	}
+	return nil
+}
+
+// allowFunc hides movies user is not interested in or has rated, movies not passing filter and movies missing in catalog
+func (s *Service) allowFunc(excluded exclusion.Set, rated map[string]struct{}, f Filter) func(movieID string) bool {
+	return func(movieID string) bool {
+		if _, ok := rated[movieID]; ok {
+			return false
+		}
+		m, ok := s.catalog.Get(movieID)
+		return ok && !excluded.Excludes(m) && f.allows(s.catalog, m)
+	}
+}
+
+// hydrate replaces candidates with catalog movies, skipping movies deleted since last refresh,
+// components of scores are kept in debug mode
+func (s *Service) hydrate(candidates []Candidate, debug bool) []Recommendation {
+	recommendations := make([]Recommendation, 0, len(candidates))
+	for _, c := range candidates {
+		m, ok := s.catalog.Get(c.MovieID)
+		if !ok {
+			continue
+		}
+		rec := Recommendation{Movie: m, Score: c.Score}
+		if debug {
+			rec.Components = c.Components
+		}
+		recommendations = append(recommendations, rec)
+	}
+	return recommendations
+}
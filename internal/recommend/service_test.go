@@ -0,0 +1,271 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/events"
+	"github.com/danyatalent/movie-recommend/internal/exclusion"
+	"github.com/danyatalent/movie-recommend/internal/experiment"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"github.com/danyatalent/movie-recommend/internal/slate"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type staticRatings []rating.Rating
+
+func (s staticRatings) GetAllRatings(context.Context) ([]rating.Rating, error) {
+	return s, nil
+}
+
+func (s staticRatings) GetUserRatings(_ context.Context, userID string) ([]rating.Rating, error) {
+	ratings := make([]rating.Rating, 0)
+	for _, r := range s {
+		if r.UserID == userID {
+			ratings = append(ratings, r)
+		}
+	}
+	return ratings, nil
+}
+
+type staticExclusions []exclusion.Exclusion
+
+func (s staticExclusions) GetExclusions(context.Context, string) ([]exclusion.Exclusion, error) {
+	return s, nil
+}
+
+type discardImpressions struct{}
+
+func (discardImpressions) Add([]events.Event) error {
+	return nil
+}
+
+type memorySlates struct{}
+
+func (memorySlates) SaveSlate(_ context.Context, s *slate.Slate) error {
+	s.ID = "slate"
+	return nil
+}
+
+type staticActivity []Activity
+
+func (s staticActivity) GetActiveUsers(context.Context, time.Time) ([]Activity, error) {
+	return s, nil
+}
+
+// newTestService serves popular movies to users with at least one rating
+func newTestService(t *testing.T, movies []movie.Movie, directors staticDirectors, ratings []rating.Rating,
+	excluded []exclusion.Exclusion) (*Service, *Cache) {
+	t.Helper()
+	return newTestServiceWith(t, NewPopular(), movies, directors, ratings, excluded)
+}
+
+// newTestServiceWith serves movies of strategy to users with at least one rating
+func newTestServiceWith(t *testing.T, strategy Strategy, movies []movie.Movie, directors staticDirectors,
+	ratings []rating.Rating, excluded []exclusion.Exclusion) (*Service, *Cache) {
+	t.Helper()
+	catalog := NewCatalog(staticMovies(movies), directors)
+	experiments, err := experiment.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router, err := NewRouter(strategy, experiments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := NewCache(30, 10)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	bandit := NewBandit(catalog, staticStats(nil), logger, Exploration{})
+	service := NewService(logger, catalog, staticRatings(ratings), router,
+		NewColdStart(catalog, staticPrefs{}), staticExclusions(excluded), NewExplainer(catalog, staticPrefs{}, staticDirectors{}),
+		discardImpressions{}, memorySlates{}, cache, bandit, NewExposure(catalog, staticExposure(nil), logger, Fairness{}),
+		Diversity{Lambda: 1}, 1)
+	if err = service.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return service, cache
+}
+
+func TestService_Recommend(t *testing.T) {
+	drama := []genre.Genre{{ID: "drama"}}
+	movies := []movie.Movie{
+		{ID: "a", DirectorID: "d1", Genres: drama},
+		{ID: "b", DirectorID: "d2", Genres: drama},
+		{ID: "c", DirectorID: "d3", Genres: drama},
+	}
+	ratings := []rating.Rating{
+		{UserID: "u1", MovieID: "a", Score: 8},
+		{UserID: "u2", MovieID: "a", Score: 8},
+		{UserID: "u2", MovieID: "b", Score: 8},
+		{UserID: "u3", MovieID: "c", Score: 8},
+	}
+	service, _ := newTestService(t, movies, nil, ratings, []exclusion.Exclusion{{Kind: exclusion.KindDirector, TargetID: "d2"}})
+
+	first, err := service.Recommend(context.Background(), Request{UserID: "u1", Limit: 5, Diversity: Diversity{Lambda: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Meta.Cached {
+		t.Errorf("first request must be computed live")
+	}
+	if len(first.Recommendations) != 1 || first.Recommendations[0].Movie.ID != "c" {
+		t.Errorf("expected only c, rated and excluded movies must be skipped: %v", first.Recommendations)
+	}
+	if first.RecommendationID != "slate" {
+		t.Errorf("expected slate id, got %q", first.RecommendationID)
+	}
+
+	second, err := service.Recommend(context.Background(), Request{UserID: "u1", Limit: 5, Diversity: Diversity{Lambda: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Meta.Cached || !second.Meta.ComputedAt.Equal(first.Meta.ComputedAt) {
+		t.Errorf("second request must be served from cache: %+v", second.Meta)
+	}
+}
+
+func TestService_RecommendFresh(t *testing.T) {
+	movies := []movie.Movie{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	ratings := []rating.Rating{
+		{UserID: "u1", MovieID: "a", Score: 8},
+		{UserID: "u2", MovieID: "b", Score: 8},
+		{UserID: "u2", MovieID: "c", Score: 8},
+	}
+	service, cache := newTestService(t, movies, nil, ratings, nil)
+	// u1 rates b after strategies were fitted
+	service.ratings = staticRatings(append(ratings, rating.Rating{UserID: "u1", MovieID: "b", Score: 3}))
+
+	got, err := service.Recommend(context.Background(), Request{UserID: "u1", Limit: 5, Diversity: Diversity{Lambda: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Recommendations) != 1 || got.Recommendations[0].Movie.ID != "c" {
+		t.Errorf("expected only c, movie rated after fitting must be skipped: %v", got.Recommendations)
+	}
+
+	if _, err = service.Recommend(context.Background(), Request{UserID: "unknown", Limit: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("unknown"); ok {
+		t.Errorf("user without ratings must not be cached on a miss")
+	}
+}
+
+func TestService_RecommendFiltered(t *testing.T) {
+	comedy := []genre.Genre{{ID: "comedy", Name: "Comedy"}}
+	movies := []movie.Movie{
+		{ID: "a", DirectorID: "d1"},
+		{ID: "b", DirectorID: "d1", Year: 1994},
+		{ID: "c", DirectorID: "d2", Genres: comedy, Duration: 5400, Year: 2005},
+		{ID: "d", DirectorID: "d2", Genres: comedy, Duration: 9000, Year: 2012},
+		{ID: "e", DirectorID: "d1", Genres: comedy, Duration: 3600},
+	}
+	directors := staticDirectors{"d1": {ID: "d1"}, "d2": {ID: "d2", HasOscar: true}}
+	ratings := []rating.Rating{{UserID: "u1", MovieID: "a", Score: 8}}
+	for _, id := range []string{"b", "b", "b", "c", "c", "d", "e"} {
+		ratings = append(ratings, rating.Rating{UserID: "u-" + id + strconv.Itoa(len(ratings)), MovieID: id, Score: 8})
+	}
+	service, _ := newTestService(t, movies, directors, ratings, nil)
+
+	hasOscar := true
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"genre name", Filter{Genres: []string{"comedy"}}, []string{"c", "d"}},
+		{"duration", Filter{Genres: []string{"comedy"}, MaxDuration: 7200}, []string{"c", "e"}},
+		{"oscar", Filter{HasOscarDirector: &hasOscar}, []string{"c", "d"}},
+		{"exclude director", Filter{ExcludeDirectors: []string{"d2"}}, []string{"b", "e"}},
+		// e has unknown year
+		{"min year", Filter{MinYear: 2000}, []string{"c", "d"}},
+		{"year range", Filter{MinYear: 1990, MaxYear: 2010}, []string{"b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.Recommend(context.Background(), Request{
+				UserID:    "u1",
+				Limit:     2,
+				Diversity: Diversity{Lambda: 1},
+				Filter:    tt.filter,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Meta.Cached {
+				t.Errorf("filtered list must be generated")
+			}
+			got := make([]string, 0, len(result.Recommendations))
+			for _, rec := range result.Recommendations {
+				got = append(got, rec.Movie.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrecomputer_Tick(t *testing.T) {
+	movies := []movie.Movie{{ID: "a"}, {ID: "b"}}
+	ratings := []rating.Rating{{UserID: "u1", MovieID: "a", Score: 8}, {UserID: "u2", MovieID: "b", Score: 8}}
+	service, cache := newTestService(t, movies, nil, ratings, nil)
+
+	activity := staticActivity{
+		{UserID: "u1", At: service.FittedAt().Add(-time.Minute)},
+		// not reflected in fitted ratings yet, it has to wait for next refresh
+		{UserID: "u2", At: service.FittedAt().Add(time.Minute)},
+	}
+	p := NewPrecomputer(service, cache, activity, slog.New(slog.NewTextHandler(io.Discard, nil)), time.Hour, time.Hour, time.Hour)
+	if err := p.Tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("u1"); !ok {
+		t.Errorf("u1 must be precomputed")
+	}
+	if _, ok := cache.Get("u2"); ok {
+		t.Errorf("u2 must wait for refit")
+	}
+	if len(p.pending) != 1 {
+		t.Errorf("expected u2 pending, got %v", p.pending)
+	}
+}
+
+// failingPopular fails for one user and serves popular movies to others
+type failingPopular struct {
+	*Popular
+	userID string
+}
+
+func (f failingPopular) Recommend(ctx context.Context, q Query) ([]Candidate, error) {
+	if q.UserID == f.userID {
+		return nil, errors.New("strategy failed")
+	}
+	return f.Popular.Recommend(ctx, q)
+}
+
+func TestPrecomputer_TickFailedUser(t *testing.T) {
+	movies := []movie.Movie{{ID: "a"}, {ID: "b"}}
+	ratings := []rating.Rating{{UserID: "u1", MovieID: "a", Score: 8}, {UserID: "u2", MovieID: "b", Score: 8}}
+	service, cache := newTestServiceWith(t, failingPopular{NewPopular(), "u1"}, movies, nil, ratings, nil)
+
+	at := service.FittedAt().Add(-time.Minute)
+	activity := staticActivity{{UserID: "u1", At: at}, {UserID: "u2", At: at}}
+	p := NewPrecomputer(service, cache, activity, slog.New(slog.NewTextHandler(io.Discard, nil)), time.Hour, time.Hour, time.Hour)
+	if err := p.Tick(context.Background()); err != nil {
+		t.Fatalf("failure of one user must not fail tick: %v", err)
+	}
+	if _, ok := cache.Get("u2"); !ok {
+		t.Errorf("u2 must be precomputed despite u1 failing")
+	}
+	// failed user stays pending and is retried on next tick
+	if _, ok := p.pending["u1"]; !ok || len(p.pending) != 1 {
+		t.Errorf("expected u1 pending, got %v", p.pending)
+	}
+}
@@ -0,0 +1,168 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/session"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// sessionHistory is how many latest views of session predict next movie
+	sessionHistory = 10
+	// sessionDecay is weight of view relative to the view after it
+	sessionDecay = 0.5
+)
+
+type SessionSource interface {
+	// GetViews returns movies of session from the latest one
+	GetViews(ctx context.Context, token string, limit int) ([]string, error)
+	GetTransitions(ctx context.Context, since time.Time, gap time.Duration) ([]session.Transition, error)
+}
+
+// Sessions predicts next movie of anonymous session by first order Markov chain mined from
+// events of users and views of sessions, chain is rebuilt in background
+type Sessions struct {
+	catalog *Catalog
+	source  SessionSource
+	logger  *slog.Logger
+	window  time.Duration
+	gap     time.Duration
+
+	mu sync.RWMutex
+	// next is probability of movies opened after movie, sorted from the most probable
+	next map[string][]Candidate
+	// entries are movies sessions most often move to, they fill lists of short or unknown sessions
+	entries []Candidate
+}
+
+// NewSessions mines transitions within window before refresh, views more than gap apart aren't linked
+func NewSessions(catalog *Catalog, source SessionSource, logger *slog.Logger, window, gap time.Duration) *Sessions {
+	return &Sessions{
+		catalog: catalog,
+		source:  source,
+		logger:  logger,
+		window:  window,
+		gap:     gap,
+		next:    make(map[string][]Candidate),
+	}
+}
+
+func (s *Sessions) Refresh(ctx context.Context) error {
+	transitions, err := s.source.GetTransitions(ctx, time.Now().Add(-s.window), s.gap)
+	if err != nil {
+		return err
+	}
+	next, entries := buildChain(transitions)
+	s.mu.Lock()
+	s.next = next
+	s.entries = entries
+	s.mu.Unlock()
+	return nil
+}
+
+// Run refreshes chain every interval until ctx is done
+func (s *Sessions) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				s.logger.Error("failed to refresh session recommender", logging.Err(err))
+			}
+		}
+	}
+}
+
+// buildChain normalizes transition counts of every movie into probabilities
+func buildChain(transitions []session.Transition) (map[string][]Candidate, []Candidate) {
+	out := make(map[string]int)
+	in := make(map[string]float64)
+	for _, t := range transitions {
+		out[t.From] += t.Count
+		in[t.To] += float64(t.Count)
+	}
+	next := make(map[string][]Candidate, len(out))
+	for _, t := range transitions {
+		next[t.From] = append(next[t.From], Candidate{MovieID: t.To, Score: float64(t.Count) / float64(out[t.From])})
+	}
+	for from := range next {
+		sortCandidates(next[from])
+	}
+	return next, rank(in, 0)
+}
+
+// Recommend returns movies likely opened next in session, every movie is explained by view which
+// contributed to it most. Short sessions are filled with common entry movies
+func (s *Sessions) Recommend(ctx context.Context, token string, limit int) ([]Recommendation, error) {
+	views, err := s.source.GetViews(ctx, token, sessionHistory)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	candidates, seeds := predict(s.next, views)
+	entries := s.entries
+	s.mu.RUnlock()
+
+	viewed := make(map[string]struct{}, len(views))
+	for _, movieID := range views {
+		viewed[movieID] = struct{}{}
+	}
+	recommendations := make([]Recommendation, 0, limit)
+	add := func(c Candidate, explanation Explanation) {
+		if _, ok := viewed[c.MovieID]; ok || len(recommendations) == limit {
+			return
+		}
+		m, ok := s.catalog.Get(c.MovieID)
+		if !ok {
+			return
+		}
+		viewed[c.MovieID] = struct{}{}
+		recommendations = append(recommendations, Recommendation{
+			Movie:        m,
+			Score:        c.Score,
+			Explanations: []Explanation{explanation},
+		})
+	}
+	for _, c := range candidates {
+		add(c, s.seedExplanation(seeds[c.MovieID], c.Score))
+	}
+	for _, c := range entries {
+		// entries never outrank predicted movies
+		add(Candidate{MovieID: c.MovieID}, Explanation{Reason: ReasonPopular, Weight: 1})
+	}
+	return recommendations, nil
+}
+
+// seedExplanation weighs seed by its share of movie score
+func (s *Sessions) seedExplanation(seed Candidate, score float64) Explanation {
+	e := Explanation{Reason: ReasonSeedMovie, Weight: seed.Score / score}
+	if m, ok := s.catalog.Get(seed.MovieID); ok {
+		e.Movie = &m
+	}
+	return e
+}
+
+// predict sums transition probabilities from views weighted by their recency, views go from the latest.
+// Seeds map every predicted movie to view contributing most and its contribution
+func predict(next map[string][]Candidate, views []string) ([]Candidate, map[string]Candidate) {
+	scores := make(map[string]float64)
+	seeds := make(map[string]Candidate)
+	weight := 1.0
+	for _, from := range views {
+		for _, c := range next[from] {
+			contribution := weight * c.Score
+			scores[c.MovieID] += contribution
+			if contribution > seeds[c.MovieID].Score {
+				seeds[c.MovieID] = Candidate{MovieID: from, Score: contribution}
+			}
+		}
+		weight *= sessionDecay
+	}
+	return rank(scores, 0), seeds
+}
@@ -0,0 +1,78 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/session"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type staticSessions struct {
+	views       []string
+	transitions []session.Transition
+}
+
+func (s staticSessions) GetViews(context.Context, string, int) ([]string, error) {
+	return s.views, nil
+}
+
+func (s staticSessions) GetTransitions(context.Context, time.Time, time.Duration) ([]session.Transition, error) {
+	return s.transitions, nil
+}
+
+func TestSessions_Recommend(t *testing.T) {
+	movies := []movie.Movie{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "e"}}
+	catalog := NewCatalog(staticMovies(movies), staticDirectors{})
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	transitions := []session.Transition{
+		{From: "a", To: "b", Count: 3},
+		{From: "a", To: "c", Count: 1},
+		{From: "b", To: "c", Count: 1},
+		{From: "b", To: "d", Count: 3},
+		{From: "c", To: "e", Count: 10},
+	}
+	tests := []struct {
+		name  string
+		views []string
+		want  []string
+		seeds []string
+	}{
+		// latest view b dominates, c gets 0.25 from b and 0.125 from a, viewed a is skipped,
+		// e has no transition from views and comes from entries
+		{"markov", []string{"b", "a"}, []string{"d", "c", "e"}, []string{"b", "b", ""}},
+		{"unknown session", nil, []string{"e", "b", "d"}, []string{"", "", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSessions(catalog, staticSessions{views: tt.views, transitions: transitions},
+				slog.New(slog.NewTextHandler(io.Discard, nil)), time.Hour, time.Hour)
+			if err := s.Refresh(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			recs, err := s.Recommend(context.Background(), "token", 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recs) != len(tt.want) {
+				t.Fatalf("got %v, want %v", recs, tt.want)
+			}
+			for i, rec := range recs {
+				if rec.Movie.ID != tt.want[i] {
+					t.Errorf("position %d: got %s, want %s", i, rec.Movie.ID, tt.want[i])
+				}
+				var seed string
+				if e := rec.Explanations[0]; e.Reason == ReasonSeedMovie {
+					seed = e.Movie.ID
+				}
+				if seed != tt.seeds[i] {
+					t.Errorf("position %d: got seed %q, want %q", i, seed, tt.seeds[i])
+				}
+			}
+		})
+	}
+}
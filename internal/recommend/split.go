@@ -0,0 +1,31 @@
+package recommend
+
+import (
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"math/rand"
+	"sort"
+)
+
+// SplitRandom moves fraction of ratings chosen at random into test set
+func SplitRandom(ratings []rating.Rating, fraction float64, seed int64) (train, test []rating.Rating) {
+	rnd := rand.New(rand.NewSource(seed))
+	for _, rt := range ratings {
+		if rnd.Float64() < fraction {
+			test = append(test, rt)
+		} else {
+			train = append(train, rt)
+		}
+	}
+	return train, test
+}
+
+// SplitByTime moves the latest fraction of ratings into test set
+func SplitByTime(ratings []rating.Rating, fraction float64) (train, test []rating.Rating) {
+	sorted := make([]rating.Rating, len(ratings))
+	copy(sorted, ratings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].RatedAt.Before(sorted[j].RatedAt)
+	})
+	cut := len(sorted) - int(float64(len(sorted))*fraction)
+	return sorted[:cut], sorted[cut:]
+}
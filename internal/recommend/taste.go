@@ -0,0 +1,249 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/director"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// dislikedScore is the highest score which adds nothing to taste
+const dislikedScore = 3
+
+type UserFeedbackSource interface {
+	GetAllRatings(ctx context.Context) ([]rating.Rating, error)
+	GetUserRatings(ctx context.Context, userID string) ([]rating.Rating, error)
+}
+
+type GenreAffinity struct {
+	Genre    genre.Genre `json:"genre"`
+	Affinity float64     `json:"affinity" example:"0.42"`
+}
+
+type DirectorAffinity struct {
+	Director director.Director `json:"director"`
+	Affinity float64           `json:"affinity" example:"0.18"`
+}
+
+// TasteProfile is share of user's feedback per genre and per director, genres sum up to 1.
+// Feedback is weighted by score and halves its weight every half-life before UpdatedAt
+type TasteProfile struct {
+	UserID    string             `json:"user_id" example:"a9aec972-2c52-441a-8f17-79506cd34366"`
+	Genres    []GenreAffinity    `json:"genres"`
+	Directors []DirectorAffinity `json:"directors"`
+	// Signals is number of movies profile is built from
+	Signals   int       `json:"signals" example:"42"`
+	UpdatedAt time.Time `json:"updated_at" example:"2024-03-17T12:00:00Z"`
+}
+
+// GenreAffinity returns affinity of genre by id, 0 for genres user showed no interest in
+func (p TasteProfile) GenreAffinity(genreID string) float64 {
+	for _, g := range p.Genres {
+		if g.Genre.ID == genreID {
+			return g.Affinity
+		}
+	}
+	return 0
+}
+
+// DirectorAffinity returns affinity of director by id, directors outside top ones have 0
+func (p TasteProfile) DirectorAffinity(directorID string) float64 {
+	for _, d := range p.Directors {
+		if d.Director.ID == directorID {
+			return d.Affinity
+		}
+	}
+	return 0
+}
+
+// Match scores movie in [0, 1] by affinities of its genres and its director
+func (p TasteProfile) Match(m movie.Movie) float64 {
+	var genres float64
+	for _, g := range m.Genres {
+		genres += p.GenreAffinity(g.ID)
+	}
+	return (math.Min(genres, 1) + p.DirectorAffinity(m.DirectorID)) / 2
+}
+
+// Profiles keeps taste profiles of all users in memory. They are built from all feedback on refresh
+// and in between every user with new feedback has own profile rebuilt on tick. Activity doesn't
+// include deleted ratings and watchlist removals, so profiles are fully rebuilt periodically
+type Profiles struct {
+	catalog      *Catalog
+	feedback     UserFeedbackSource
+	activity     ActivitySource
+	logger       *slog.Logger
+	halfLife     time.Duration
+	topDirectors int
+
+	mu       sync.RWMutex
+	profiles map[string]TasteProfile
+	since    time.Time
+}
+
+func NewProfiles(catalog *Catalog, feedback UserFeedbackSource, activity ActivitySource, logger *slog.Logger,
+	halfLife time.Duration, topDirectors int) *Profiles {
+	return &Profiles{
+		catalog:      catalog,
+		feedback:     feedback,
+		activity:     activity,
+		logger:       logger,
+		halfLife:     halfLife,
+		topDirectors: topDirectors,
+		profiles:     make(map[string]TasteProfile),
+	}
+}
+
+// Refresh rebuilds profiles of every user
+func (p *Profiles) Refresh(ctx context.Context) error {
+	readAt := time.Now()
+	ratings, err := p.feedback.GetAllRatings(ctx)
+	if err != nil {
+		return err
+	}
+	byUser := make(map[string][]rating.Rating)
+	for _, rt := range ratings {
+		byUser[rt.UserID] = append(byUser[rt.UserID], rt)
+	}
+	profiles := make(map[string]TasteProfile, len(byUser))
+	for userID, userRatings := range byUser {
+		profiles[userID] = p.build(userID, userRatings, readAt)
+	}
+	p.mu.Lock()
+	p.profiles = profiles
+	p.since = readAt.Add(-activityLag)
+	p.mu.Unlock()
+	return nil
+}
+
+// Run updates profiles of users with new feedback every interval and rebuilds all of them every
+// rebuildInterval until ctx is done, rebuild also picks up feedback on movies added to catalog later
+func (p *Profiles) Run(ctx context.Context, interval, rebuildInterval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	rebuild := time.NewTicker(rebuildInterval)
+	defer rebuild.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Tick(ctx); err != nil {
+				p.logger.Error("failed to update taste profiles", logging.Err(err))
+			}
+		case <-rebuild.C:
+			if err := p.Refresh(ctx); err != nil {
+				p.logger.Error("failed to rebuild taste profiles", logging.Err(err))
+			}
+		}
+	}
+}
+
+// Tick rebuilds profiles of users active since previous tick
+func (p *Profiles) Tick(ctx context.Context) error {
+	now := time.Now()
+	p.mu.RLock()
+	since := p.since
+	p.mu.RUnlock()
+	activity, err := p.activity.GetActiveUsers(ctx, since)
+	if err != nil {
+		return err
+	}
+	for _, a := range activity {
+		ratings, err := p.feedback.GetUserRatings(ctx, a.UserID)
+		if err != nil {
+			return err
+		}
+		profile := p.build(a.UserID, ratings, now)
+		p.mu.Lock()
+		p.profiles[a.UserID] = profile
+		p.mu.Unlock()
+	}
+	p.mu.Lock()
+	p.since = now.Add(-activityLag)
+	p.mu.Unlock()
+	if len(activity) > 0 {
+		p.logger.Info("taste profiles updated", slog.Int("users", len(activity)))
+	}
+	return nil
+}
+
+// Profile returns profile of user, empty for users without feedback
+func (p *Profiles) Profile(userID string) TasteProfile {
+	p.mu.RLock()
+	profile, ok := p.profiles[userID]
+	p.mu.RUnlock()
+	if !ok {
+		return TasteProfile{UserID: userID, Genres: []GenreAffinity{}, Directors: []DirectorAffinity{}}
+	}
+	return profile
+}
+
+// build accumulates recency weighted score of feedback per genre and director of rated movies
+func (p *Profiles) build(userID string, ratings []rating.Rating, now time.Time) TasteProfile {
+	profile := TasteProfile{UserID: userID, UpdatedAt: now}
+	genres := make(map[string]float64)
+	genresByID := make(map[string]genre.Genre)
+	directors := make(map[string]float64)
+	var total, genresTotal float64
+	for _, rt := range ratings {
+		m, ok := p.catalog.Get(rt.MovieID)
+		if !ok {
+			continue
+		}
+		profile.Signals++
+		weight := float64(max(rt.Score-dislikedScore, 0))
+		if p.halfLife > 0 && now.After(rt.RatedAt) {
+			weight *= math.Pow(0.5, float64(now.Sub(rt.RatedAt))/float64(p.halfLife))
+		}
+		if weight == 0 {
+			continue
+		}
+		total += weight
+		for _, g := range m.Genres {
+			genres[g.ID] += weight
+			genresByID[g.ID] = g
+			genresTotal += weight
+		}
+		if m.DirectorID != "" {
+			directors[m.DirectorID] += weight
+		}
+	}
+
+	profile.Genres = make([]GenreAffinity, 0, len(genres))
+	for id, weight := range genres {
+		profile.Genres = append(profile.Genres, GenreAffinity{Genre: genresByID[id], Affinity: weight / genresTotal})
+	}
+	sort.Slice(profile.Genres, func(i, j int) bool {
+		if profile.Genres[i].Affinity != profile.Genres[j].Affinity {
+			return profile.Genres[i].Affinity > profile.Genres[j].Affinity
+		}
+		return profile.Genres[i].Genre.ID < profile.Genres[j].Genre.ID
+	})
+
+	profile.Directors = make([]DirectorAffinity, 0, len(directors))
+	for id, weight := range directors {
+		d, ok := p.catalog.Director(id)
+		if !ok {
+			d = director.Director{ID: id}
+		}
+		profile.Directors = append(profile.Directors, DirectorAffinity{Director: d, Affinity: weight / total})
+	}
+	sort.Slice(profile.Directors, func(i, j int) bool {
+		if profile.Directors[i].Affinity != profile.Directors[j].Affinity {
+			return profile.Directors[i].Affinity > profile.Directors[j].Affinity
+		}
+		return profile.Directors[i].Director.ID < profile.Directors[j].Director.ID
+	})
+	if len(profile.Directors) > p.topDirectors {
+		profile.Directors = profile.Directors[:p.topDirectors]
+	}
+	return profile
+}
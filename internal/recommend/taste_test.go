@@ -0,0 +1,81 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+)
+
+type memoryFeedback []rating.Rating
+
+func (m *memoryFeedback) GetAllRatings(context.Context) ([]rating.Rating, error) {
+	return *m, nil
+}
+
+func (m *memoryFeedback) GetUserRatings(_ context.Context, userID string) ([]rating.Rating, error) {
+	ratings := make([]rating.Rating, 0)
+	for _, rt := range *m {
+		if rt.UserID == userID {
+			ratings = append(ratings, rt)
+		}
+	}
+	return ratings, nil
+}
+
+func TestProfiles(t *testing.T) {
+	drama := genre.Genre{ID: "drama", Name: "Drama"}
+	comedy := genre.Genre{ID: "comedy", Name: "Comedy"}
+	movies := []movie.Movie{
+		{ID: "a", DirectorID: "d1", Genres: []genre.Genre{drama}},
+		{ID: "b", DirectorID: "d2", Genres: []genre.Genre{comedy}},
+		{ID: "c", DirectorID: "d2", Genres: []genre.Genre{drama, comedy}},
+	}
+	catalog := NewCatalog(staticMovies(movies), staticDirectors{"d1": {ID: "d1", LastName: "Nolan"}})
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	feedback := &memoryFeedback{
+		{UserID: "u1", MovieID: "a", Score: 10, RatedAt: now},
+		// a year old feedback weighs 1/16 with quarter half-life
+		{UserID: "u1", MovieID: "b", Score: 10, RatedAt: now.Add(-365 * 24 * time.Hour)},
+		// dislike adds nothing
+		{UserID: "u1", MovieID: "c", Score: 2, RatedAt: now},
+	}
+	profiles := NewProfiles(catalog, feedback, staticActivity{{UserID: "u1", At: now}}, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		365*24*time.Hour/4, 1)
+	if err := profiles.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	p := profiles.Profile("u1")
+	if p.Signals != 3 || len(p.Genres) != 2 || p.Genres[0].Genre.ID != "drama" {
+		t.Fatalf("unexpected profile: %+v", p)
+	}
+	if got, want := p.GenreAffinity("drama"), 16.0/17; math.Abs(got-want) > 1e-3 {
+		t.Errorf("drama affinity %v, want %v", got, want)
+	}
+	if len(p.Directors) != 1 || p.Directors[0].Director.LastName != "Nolan" {
+		t.Errorf("expected only top director resolved from catalog: %+v", p.Directors)
+	}
+
+	*feedback = append(*feedback,
+		rating.Rating{UserID: "u1", MovieID: "b", Score: 10, RatedAt: now},
+		rating.Rating{UserID: "u1", MovieID: "c", Score: 10, RatedAt: now},
+	)
+	if err := profiles.Tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if p = profiles.Profile("u1"); p.Genres[0].Genre.ID != "comedy" {
+		t.Errorf("fresh comedy feedback must lead after update: %+v", p.Genres)
+	}
+	if empty := profiles.Profile("u2"); empty.Signals != 0 || empty.Genres == nil {
+		t.Errorf("unknown user must get empty profile: %+v", empty)
+	}
+}
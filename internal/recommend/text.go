@@ -0,0 +1,60 @@
+package recommend
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minTokenLength drops single letters and most abbreviations
+const minTokenLength = 2
+
+var stopwords = toSet(
+	// english
+	"a", "about", "above", "after", "again", "against", "all", "also", "am", "an", "and", "any", "are", "as", "at",
+	"be", "because", "been", "before", "being", "below", "between", "both", "but", "by", "can", "could", "did", "do",
+	"does", "doing", "down", "during", "each", "even", "ever", "every", "few", "for", "from", "further", "get", "gets",
+	"had", "has", "have", "having", "he", "her", "here", "hers", "herself", "him", "himself", "his", "how", "however",
+	"if", "in", "into", "is", "it", "its", "itself", "just", "least", "less", "like", "many", "may", "me", "might",
+	"more", "most", "much", "must", "my", "myself", "never", "no", "nor", "not", "now", "of", "off", "on", "once",
+	"one", "only", "or", "other", "our", "ours", "ourselves", "out", "over", "own", "same", "she", "should", "so",
+	"some", "still", "such", "than", "that", "the", "their", "theirs", "them", "themselves", "then", "there", "these",
+	"they", "this", "those", "through", "to", "too", "under", "until", "up", "upon", "us", "very", "was", "we", "were",
+	"what", "when", "where", "whether", "which", "while", "who", "whom", "whose", "why", "will", "with", "within",
+	"without", "would", "yet", "you", "your", "yours", "yourself", "yourselves",
+	// russian
+	"а", "без", "более", "бы", "был", "была", "были", "было", "быть", "в", "вам", "вас", "весь", "во", "вот", "все",
+	"всего", "всех", "вы", "где", "да", "даже", "для", "до", "его", "ее", "ей", "ему", "если", "есть", "еще", "же",
+	"за", "здесь", "и", "из", "или", "им", "их", "к", "как", "какой", "когда", "кто", "ли", "либо", "между", "меня",
+	"мне", "много", "может", "мы", "на", "над", "надо", "наш", "не", "него", "нее", "нет", "ни", "них", "но", "ну",
+	"о", "об", "однако", "он", "она", "они", "оно", "от", "очень", "по", "под", "после", "при", "про", "с", "сам",
+	"свой", "себе", "себя", "со", "так", "также", "такой", "там", "те", "тем", "то", "того", "тоже", "той", "только",
+	"том", "ты", "у", "уже", "хотя", "чего", "чей", "чем", "что", "чтобы", "чье", "эта", "эти", "это", "этого",
+	"этой", "этом", "этот", "я",
+)
+
+func toSet(words ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		set[w] = struct{}{}
+	}
+	return set
+}
+
+// Tokenize splits text into lower case words and numbers, dropping stopwords and too short tokens
+func Tokenize(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "ё", "е")
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if len([]rune(f)) < minTokenLength {
+			continue
+		}
+		if _, ok := stopwords[f]; ok {
+			continue
+		}
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
@@ -0,0 +1,131 @@
+package recommend
+
+import (
+	"math"
+	"sync"
+)
+
+// TextIndex keeps TF-IDF vectors of documents. Only term frequencies are stored, idf is applied
+// when documents are compared, so adding, changing or removing document touches only its own terms
+type TextIndex struct {
+	mu sync.RWMutex
+	// docs holds term frequencies of every document, postings holds the same by term
+	docs     map[string]map[string]float64
+	postings map[string]map[string]float64
+}
+
+func NewTextIndex() *TextIndex {
+	return &TextIndex{
+		docs:     make(map[string]map[string]float64),
+		postings: make(map[string]map[string]float64),
+	}
+}
+
+// Upsert indexes text of document replacing its previous version
+func (x *TextIndex) Upsert(id, text string) {
+	counts := make(map[string]int)
+	for _, token := range Tokenize(text) {
+		counts[token]++
+	}
+	tf := make(map[string]float64, len(counts))
+	for term, n := range counts {
+		// sublinear tf so that repeated word doesn't dominate description
+		tf[term] = 1 + math.Log(float64(n))
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+	if len(tf) == 0 {
+		return
+	}
+	x.docs[id] = tf
+	for term, f := range tf {
+		if x.postings[term] == nil {
+			x.postings[term] = make(map[string]float64)
+		}
+		x.postings[term][id] = f
+	}
+}
+
+func (x *TextIndex) Remove(id string) {
+	x.mu.Lock()
+	x.remove(id)
+	x.mu.Unlock()
+}
+
+func (x *TextIndex) remove(id string) {
+	for term := range x.docs[id] {
+		delete(x.postings[term], id)
+		if len(x.postings[term]) == 0 {
+			delete(x.postings, term)
+		}
+	}
+	delete(x.docs, id)
+}
+
+// Len returns number of indexed documents, documents without meaningful words aren't indexed
+func (x *TextIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs)
+}
+
+// idf is smoothed so that term present in every document still counts a little
+func (x *TextIndex) idf(term string) float64 {
+	return math.Log(float64(1+len(x.docs))/float64(1+len(x.postings[term]))) + 1
+}
+
+func (x *TextIndex) norm(tf map[string]float64) float64 {
+	var sum float64
+	for term, f := range tf {
+		w := f * x.idf(term)
+		sum += w * w
+	}
+	return math.Sqrt(sum)
+}
+
+// Similarity returns cosine similarity of TF-IDF vectors of two documents
+func (x *TextIndex) Similarity(a, b string) float64 {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	tfA, tfB := x.docs[a], x.docs[b]
+	if len(tfA) == 0 || len(tfB) == 0 {
+		return 0
+	}
+	var dot float64
+	for term, f := range tfA {
+		if g, ok := tfB[term]; ok {
+			idf := x.idf(term)
+			dot += f * g * idf * idf
+		}
+	}
+	if dot == 0 {
+		return 0
+	}
+	return dot / (x.norm(tfA) * x.norm(tfB))
+}
+
+// Similar returns cosine similarity of document to every document sharing a term with it
+func (x *TextIndex) Similar(id string) map[string]float64 {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	tf := x.docs[id]
+	dots := make(map[string]float64)
+	for term, f := range tf {
+		idf := x.idf(term)
+		for other, g := range x.postings[term] {
+			if other != id {
+				dots[other] += f * g * idf * idf
+			}
+		}
+	}
+	if len(dots) == 0 {
+		return dots
+	}
+	norm := x.norm(tf)
+	for other, dot := range dots {
+		dots[other] = dot / (norm * x.norm(x.docs[other]))
+	}
+	return dots
+}
@@ -0,0 +1,116 @@
+package recommend
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "english",
+			text: "The crew of a ship travels through a wormhole, in 2067!",
+			want: []string{"crew", "ship", "travels", "wormhole", "2067"},
+		},
+		{
+			name: "russian",
+			text: "Ещё один фильм о том, как ёж и он ищут дом",
+			want: []string{"один", "фильм", "еж", "ищут", "дом"},
+		},
+		{
+			name: "only stopwords",
+			text: "and so it is",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tokenize(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("Tokenize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextIndex(t *testing.T) {
+	x := NewTextIndex()
+	x.Upsert("interstellar", "Astronauts travel through a wormhole to find a new home for humanity")
+	x.Upsert("contact", "Scientist finds a signal and travels through a wormhole to meet aliens")
+	x.Upsert("notebook", "A poor young man falls in love with a rich young woman")
+
+	if s := x.Similarity("interstellar", "contact"); s <= 0 || s > 1 {
+		t.Errorf("interstellar and contact must be similar, got %f", s)
+	}
+	if s := x.Similarity("interstellar", "notebook"); s != 0 {
+		t.Errorf("interstellar and notebook share no words, got %f", s)
+	}
+	if s := x.Similarity("contact", "contact"); s < 0.999 {
+		t.Errorf("document must be similar to itself, got %f", s)
+	}
+
+	similar := x.Similar("interstellar")
+	if _, ok := similar["notebook"]; ok || len(similar) != 1 {
+		t.Errorf("only contact must be similar to interstellar, got %v", similar)
+	}
+	if math.Abs(similar["contact"]-x.Similarity("interstellar", "contact")) > 1e-9 {
+		t.Errorf("Similar and Similarity disagree: %f != %f", similar["contact"], x.Similarity("interstellar", "contact"))
+	}
+
+	// edited description replaces previous one
+	x.Upsert("notebook", "A young man travels through a wormhole to find love")
+	if s := x.Similarity("interstellar", "notebook"); s <= 0 {
+		t.Errorf("edited notebook must be similar to interstellar, got %f", s)
+	}
+	x.Remove("contact")
+	if _, ok := x.Similar("interstellar")["contact"]; ok || x.Len() != 2 {
+		t.Errorf("removed document must leave index, %d documents left", x.Len())
+	}
+
+	// incremental updates end up with the same vectors as indexing from scratch
+	fresh := NewTextIndex()
+	fresh.Upsert("interstellar", "Astronauts travel through a wormhole to find a new home for humanity")
+	fresh.Upsert("notebook", "A young man travels through a wormhole to find love")
+	if got, want := x.Similarity("interstellar", "notebook"), fresh.Similarity("interstellar", "notebook"); math.Abs(got-want) > 1e-9 {
+		t.Errorf("incremental index differs from rebuilt one: %f != %f", got, want)
+	}
+}
+
+func TestContent_SimilarByDescription(t *testing.T) {
+	movies := []movie.Movie{
+		{ID: "interstellar", DirectorID: "nolan", Description: "Astronauts travel through a wormhole to save humanity"},
+		{ID: "contact", DirectorID: "zemeckis", Description: "Astronauts travel through a wormhole to meet aliens"},
+		{ID: "notebook", DirectorID: "cassavetes", Description: "A poor young man falls in love"},
+	}
+	catalog := NewCatalog(staticMovies(movies), staticDirectors{})
+	if err := catalog.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	content := NewContent(catalog, staticExclusions(nil))
+
+	similar, err := content.Similar(context.Background(), "interstellar", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(similar) != 1 || similar[0].Movie.ID != "contact" || !containsFeature(similar[0].Matched, FeatureDescription) {
+		t.Fatalf("only contact must be similar by description, got %v", similar)
+	}
+
+	// edited movie is indexed without refresh
+	notebook := movies[2]
+	notebook.Description = "Astronauts travel through a wormhole and fall in love"
+	catalog.Upsert(notebook)
+	similar, err = content.Similar(context.Background(), "interstellar", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(similar) != 2 {
+		t.Errorf("edited notebook must become similar, got %v", similar)
+	}
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/session"
+	"github.com/danyatalent/movie-recommend/pkg/client/postgresql"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/jackc/pgx/v5/pgconn"
+	"log/slog"
+	"time"
+)
+
+type Repository struct {
+	client postgresql.Client
+	logger *slog.Logger
+}
+
+func NewRepository(client postgresql.Client, logger *slog.Logger) *Repository {
+	return &Repository{
+		client: client,
+		logger: logger,
+	}
+}
+
+func (r *Repository) AddView(ctx context.Context, v *session.View) error {
+	q := "insert into session_views(token, movie_id) values ($1, $2) returning viewed_at"
+	r.logger.Info("adding session view", slog.String("query", q))
+	if err := r.client.QueryRow(ctx, q, v.Token, v.MovieID).Scan(&v.ViewedAt); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.SQLState() == apperror.ErrForeignKeyCode {
+				return apperror.ErrEntityNotFound
+			}
+			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Code: %s, SQLState: %s",
+				pgErr.Message, pgErr.Detail, pgErr.Code, pgErr.SQLState()))
+			r.logger.Error("error due query", logging.Err(newErr))
+			return newErr
+		}
+		return err
+	}
+	return nil
+}
+
+// GetViews returns movies of session ordered from the latest one, at most limit
+func (r *Repository) GetViews(ctx context.Context, token string, limit int) ([]string, error) {
+	q := "select movie_id from session_views where token=$1 order by viewed_at desc, id desc limit $2"
+	r.logger.Debug("getting session views", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q, token, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	views := make([]string, 0, limit)
+	for rows.Next() {
+		var movieID string
+		if err = rows.Scan(&movieID); err != nil {
+			return nil, err
+		}
+		views = append(views, movieID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+// GetTransitions mines consecutive movies of anonymous sessions and of users' non impression events
+// since given time. Movies opened more than gap apart are considered different sessions
+func (r *Repository) GetTransitions(ctx context.Context, since time.Time, gap time.Duration) ([]session.Transition, error) {
+	q := `select prev_id, movie_id, count(*)
+		  from (select movie_id, occurred_at as at,
+					   lag(movie_id) over w as prev_id, lag(occurred_at) over w as prev_at
+				from events
+				where type <> 'impression' and occurred_at >= $1
+				window w as (partition by user_id order by occurred_at, id)
+				union all
+				select movie_id, viewed_at,
+					   lag(movie_id) over w, lag(viewed_at) over w
+				from session_views
+				where viewed_at >= $1
+				window w as (partition by token order by viewed_at, id)) t
+		  where prev_id is not null and prev_id <> movie_id and at - prev_at <= make_interval(secs => $2)
+		  group by prev_id, movie_id`
+	r.logger.Debug("getting transitions", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q, since, gap.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	transitions := make([]session.Transition, 0)
+	for rows.Next() {
+		var t session.Transition
+		if err = rows.Scan(&t.From, &t.To, &t.Count); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return transitions, nil
+}
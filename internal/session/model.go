@@ -0,0 +1,17 @@
+package session
+
+import "time"
+
+// View is movie opened by anonymous visitor, Token identifies visitor's session
+type View struct {
+	Token    string    `json:"token" example:"3f1c2a9e7b4d4e0c"`
+	MovieID  string    `json:"movie_id" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+	ViewedAt time.Time `json:"viewed_at" example:"2024-03-17T12:00:00Z"`
+}
+
+// Transition counts how many times movie To was opened right after movie From
+type Transition struct {
+	From  string
+	To    string
+	Count int
+}
@@ -0,0 +1,280 @@
+package slate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/slate"
+	"github.com/danyatalent/movie-recommend/pkg/client/postgresql"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+type Repository struct {
+	client postgresql.Client
+	logger *slog.Logger
+}
+
+func NewRepository(client postgresql.Client, logger *slog.Logger) *Repository {
+	return &Repository{
+		client: client,
+		logger: logger,
+	}
+}
+
+// SaveSlate stores slate with its items and fills its id and serving time
+func (r *Repository) SaveSlate(ctx context.Context, s *slate.Slate) error {
+	q := `insert into recommendation_slates(user_id, strategy, experiment, variant)
+		  values ($1, $2, nullif($3, ''), nullif($4, '')) returning id, served_at`
+	r.logger.Debug("saving slate", slog.String("user_id", s.UserID))
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = tx.QueryRow(ctx, q, s.UserID, s.Strategy, s.Experiment, s.Variant).Scan(&s.ID, &s.ServedAt); err != nil {
+		return r.wrapError(err)
+	}
+	rows := make([][]any, 0, len(s.Items))
+	for _, item := range s.Items {
+		rows = append(rows, []any{s.ID, item.MovieID, int16(item.Position), item.Score, item.Exploration})
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"recommendation_slate_items"},
+		[]string{"slate_id", "movie_id", "position", "score", "exploration"}, pgx.CopyFromRows(rows))
+	if err != nil {
+		return fmt.Errorf("can't save slate items: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// SaveFeedback records click or dismissal of movie, movie must be part of the slate
+func (r *Repository) SaveFeedback(ctx context.Context, f *slate.Feedback) error {
+	q := `insert into recommendation_feedback(slate_id, movie_id, action)
+		  values ($1, $2, $3) returning created_at`
+	r.logger.Info("saving feedback", slog.String("query", q))
+	if err := r.client.QueryRow(ctx, q, f.SlateID, f.MovieID, f.Action).Scan(&f.CreatedAt); err != nil {
+		return r.wrapError(err)
+	}
+	return nil
+}
+
+func (r *Repository) wrapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if pgErr.SQLState() == apperror.ErrForeignKeyCode {
+			return apperror.ErrEntityNotFound
+		}
+		newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Code: %s, SQLState: %s",
+			pgErr.Message, pgErr.Detail, pgErr.Code, pgErr.SQLState()))
+		r.logger.Error("error due query", logging.Err(newErr))
+		return newErr
+	}
+	return err
+}
+
+// GetReport computes CTR of slates served since given time
+func (r *Repository) GetReport(ctx context.Context, since time.Time) (slate.Report, error) {
+	report := slate.Report{Since: since}
+	var err error
+	if report.ByStrategy, err = r.ctr(ctx, "s.strategy", "", "s.strategy", since); err != nil {
+		return slate.Report{}, err
+	}
+	if report.ByPosition, err = r.ctr(ctx, "i.position::text", "", "min(i.position)", since); err != nil {
+		return slate.Report{}, err
+	}
+	genres := `join movies_genres mg on mg.movie_id = i.movie_id
+			   join genres g on g.id = mg.genre_id`
+	if report.ByGenre, err = r.ctr(ctx, "g.name", genres, "g.name", since); err != nil {
+		return slate.Report{}, err
+	}
+	exploration := "case when i.exploration then 'exploration' else 'exploitation' end"
+	if report.ByExploration, err = r.ctr(ctx, exploration, "", exploration, since); err != nil {
+		return slate.Report{}, err
+	}
+	return report, nil
+}
+
+// GetMovieStats returns stats of every movie, movies never served have zero impressions
+func (r *Repository) GetMovieStats(ctx context.Context) ([]slate.MovieStats, error) {
+	q := `select m.id, m.created_at, coalesce(s.impressions, 0), coalesce(s.clicks, 0)
+		  from movies m
+		  left join (select i.movie_id, count(*) as impressions, count(*) filter (where f.clicked) as clicks
+					 from recommendation_slate_items i
+					 left join (select slate_id, movie_id, bool_or(action = 'click') as clicked
+								from recommendation_feedback
+								group by slate_id, movie_id) f on f.slate_id = i.slate_id and f.movie_id = i.movie_id
+					 group by i.movie_id) s on s.movie_id = m.id`
+	r.logger.Debug("getting movie stats", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	stats := make([]slate.MovieStats, 0)
+	for rows.Next() {
+		var st slate.MovieStats
+		if err = rows.Scan(&st.MovieID, &st.CreatedAt, &st.Impressions, &st.Clicks); err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// exposureExpr is position weighted exposure of served item
+const exposureExpr = "sum(1 / log(2, i.position + 1.0))::float8"
+
+// GetExposure returns exposure of every movie served since given time
+func (r *Repository) GetExposure(ctx context.Context, since time.Time) ([]slate.Exposure, error) {
+	q := `select i.movie_id, ` + exposureExpr + `
+		  from recommendation_slates s
+		  join recommendation_slate_items i on i.slate_id = s.id
+		  where s.served_at >= $1
+		  group by i.movie_id`
+	r.logger.Debug("getting exposure", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	exposure := make([]slate.Exposure, 0)
+	for rows.Next() {
+		var e slate.Exposure
+		if err = rows.Scan(&e.MovieID, &e.Exposure); err != nil {
+			return nil, err
+		}
+		exposure = append(exposure, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return exposure, nil
+}
+
+// GetExposureReport splits exposure of slates served since given time by country and oscar of director
+// and by director, bucket is period accepted by date_trunc
+func (r *Repository) GetExposureReport(ctx context.Context, since time.Time, bucket string, topDirectors int) (slate.ExposureReport, error) {
+	report := slate.ExposureReport{Since: since, Bucket: bucket, Periods: make([]slate.ExposurePeriod, 0)}
+	byCountry, err := r.exposure(ctx, "d.country", since, bucket)
+	if err != nil {
+		return slate.ExposureReport{}, err
+	}
+	byOscar, err := r.exposure(ctx, "case when d.has_oscar then 'oscar' else 'non_oscar' end", since, bucket)
+	if err != nil {
+		return slate.ExposureReport{}, err
+	}
+	byDirector, err := r.exposure(ctx, "d.first_name || ' ' || d.last_name", since, bucket)
+	if err != nil {
+		return slate.ExposureReport{}, err
+	}
+	// every movie has exactly one oscar group, so its sum is total exposure of period
+	for _, start := range sortedPeriods(byOscar) {
+		period := slate.ExposurePeriod{
+			Start:      start,
+			ByCountry:  byCountry[start],
+			ByOscar:    byOscar[start],
+			ByDirector: byDirector[start],
+		}
+		for _, share := range period.ByOscar {
+			period.Exposure += share.Exposure
+		}
+		for _, shares := range [][]slate.ExposureShare{period.ByCountry, period.ByOscar, period.ByDirector} {
+			for i := range shares {
+				shares[i].Share = shares[i].Exposure / period.Exposure
+			}
+		}
+		period.ByDirector = period.ByDirector[:min(len(period.ByDirector), topDirectors)]
+		report.Periods = append(report.Periods, period)
+	}
+	return report, nil
+}
+
+// exposure groups exposure of served items by period and key expression over directors d, biggest first
+func (r *Repository) exposure(ctx context.Context, key string, since time.Time, bucket string) (map[time.Time][]slate.ExposureShare, error) {
+	q := fmt.Sprintf(`select date_trunc($2, s.served_at), %s, %s
+		  from recommendation_slates s
+		  join recommendation_slate_items i on i.slate_id = s.id
+		  join movies m on m.id = i.movie_id
+		  join directors d on d.id = m.director_id
+		  where s.served_at >= $1
+		  group by 1, 2
+		  order by 1, 3 desc`, key, exposureExpr)
+	r.logger.Debug("computing exposure", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q, since, bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	periods := make(map[time.Time][]slate.ExposureShare)
+	for rows.Next() {
+		var (
+			start time.Time
+			share slate.ExposureShare
+		)
+		if err = rows.Scan(&start, &share.Key, &share.Exposure); err != nil {
+			return nil, err
+		}
+		periods[start] = append(periods[start], share)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+func sortedPeriods(periods map[time.Time][]slate.ExposureShare) []time.Time {
+	starts := make([]time.Time, 0, len(periods))
+	for start := range periods {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+	return starts
+}
+
+// ctr groups served items by key expression, join adds tables key refers to
+func (r *Repository) ctr(ctx context.Context, key, join, order string, since time.Time) ([]slate.CTR, error) {
+	q := fmt.Sprintf(`select %[1]s, count(*), count(f.clicked) filter (where f.clicked), count(f.dismissed) filter (where f.dismissed)
+		  from recommendation_slates s
+		  join recommendation_slate_items i on i.slate_id = s.id
+		  %[2]s
+		  left join (select slate_id, movie_id,
+							bool_or(action = 'click') as clicked,
+							bool_or(action = 'dismiss') as dismissed
+					 from recommendation_feedback
+					 group by slate_id, movie_id) f on f.slate_id = i.slate_id and f.movie_id = i.movie_id
+		  where s.served_at >= $1
+		  group by %[1]s
+		  order by %[3]s`, key, join, order)
+	r.logger.Debug("computing ctr", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	report := make([]slate.CTR, 0)
+	for rows.Next() {
+		var c slate.CTR
+		if err = rows.Scan(&c.Key, &c.Impressions, &c.Clicks, &c.Dismissals); err != nil {
+			return nil, err
+		}
+		if c.Impressions > 0 {
+			c.CTR = float64(c.Clicks) / float64(c.Impressions)
+		}
+		report = append(report, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,91 @@
+package slate
+
+import "time"
+
+const (
+	ActionClick   = "click"
+	ActionDismiss = "dismiss"
+)
+
+// Slate is recommendations list exactly as it was served
+type Slate struct {
+	ID         string    `json:"id" example:"5c0e2b0f-5a43-4d0c-9a0e-31a1f1c7e1a4"`
+	UserID     string    `json:"user_id" example:"a9aec972-2c52-441a-8f17-79506cd34366"`
+	Strategy   string    `json:"strategy" example:"item-cf"`
+	Experiment string    `json:"experiment,omitempty" example:"als-vs-item-cf"`
+	Variant    string    `json:"variant,omitempty" example:"treatment"`
+	Items      []Item    `json:"items"`
+	ServedAt   time.Time `json:"served_at" example:"2024-03-17T12:00:00Z"`
+}
+
+type Item struct {
+	MovieID string `json:"movie_id" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+	// Position starts from 1
+	Position int     `json:"position" example:"1"`
+	Score    float64 `json:"score" example:"8.4"`
+	// Exploration marks movie put into slate by bandit rather than by strategy
+	Exploration bool `json:"exploration" example:"false"`
+}
+
+// Feedback is user reaction to movie of served slate
+type Feedback struct {
+	SlateID   string    `json:"slate_id" example:"5c0e2b0f-5a43-4d0c-9a0e-31a1f1c7e1a4"`
+	MovieID   string    `json:"movie_id" example:"dc26760a-42ba-4335-92f4-e9c0f1a2a838"`
+	Action    string    `json:"action" example:"click"`
+	CreatedAt time.Time `json:"created_at" example:"2024-03-17T12:00:00Z"`
+}
+
+// CTR of served movies grouped by Key, a movie clicked several times in one slate counts once
+type CTR struct {
+	Key         string  `json:"key" example:"item-cf"`
+	Impressions int     `json:"impressions" example:"1200"`
+	Clicks      int     `json:"clicks" example:"84"`
+	Dismissals  int     `json:"dismissals" example:"12"`
+	CTR         float64 `json:"ctr" example:"0.07"`
+}
+
+type Report struct {
+	Since      time.Time `json:"since" example:"2024-03-10T12:00:00Z"`
+	ByStrategy []CTR     `json:"by_strategy"`
+	ByPosition []CTR     `json:"by_position"`
+	ByGenre    []CTR     `json:"by_genre"`
+	// ByExploration compares movies picked by bandit with movies picked by strategies
+	ByExploration []CTR `json:"by_exploration"`
+}
+
+// MovieStats are all time impressions and clicks of movie in served slates
+type MovieStats struct {
+	MovieID     string
+	CreatedAt   time.Time
+	Impressions int
+	Clicks      int
+}
+
+// Exposure of movie in served slates, item at position p adds 1/log2(p+1) so that top positions count more
+type Exposure struct {
+	MovieID  string
+	Exposure float64
+}
+
+// ExposureShare is exposure of movies grouped by Key and its share of all exposure of period
+type ExposureShare struct {
+	Key      string  `json:"key" example:"Russia"`
+	Exposure float64 `json:"exposure" example:"120.5"`
+	Share    float64 `json:"share" example:"0.12"`
+}
+
+type ExposurePeriod struct {
+	Start     time.Time       `json:"start" example:"2024-03-17T00:00:00Z"`
+	Exposure  float64         `json:"exposure" example:"1004.2"`
+	ByCountry []ExposureShare `json:"by_country"`
+	ByOscar   []ExposureShare `json:"by_oscar"`
+	// ByDirector has only directors with the most exposure
+	ByDirector []ExposureShare `json:"by_director"`
+}
+
+// ExposureReport is distribution of exposure of served slates per period of Bucket length
+type ExposureReport struct {
+	Since   time.Time        `json:"since" example:"2024-03-10T12:00:00Z"`
+	Bucket  string           `json:"bucket" example:"day"`
+	Periods []ExposurePeriod `json:"periods"`
+}
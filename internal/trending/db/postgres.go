@@ -0,0 +1,81 @@
+package trending
+
+import (
+	"context"
+	"github.com/danyatalent/movie-recommend/internal/trending"
+	"github.com/danyatalent/movie-recommend/pkg/client/postgresql"
+	"log/slog"
+	"time"
+)
+
+type Repository struct {
+	client postgresql.Client
+	logger *slog.Logger
+}
+
+func NewRepository(client postgresql.Client, logger *slog.Logger) *Repository {
+	return &Repository{
+		client: client,
+		logger: logger,
+	}
+}
+
+// GetActivity sums weighted ratings and events per movie and hour since given time, impressions are skipped
+// since server logs them itself on every served list and trending movies would keep trending
+func (r *Repository) GetActivity(ctx context.Context, since time.Time) ([]trending.Activity, error) {
+	q := `select movie_id, sum(weight), date_trunc('hour', at) as hour
+		  from (
+			  select movie_id, 3.0 as weight, rated_at as at from ratings where rated_at >= $1
+			  union all
+			  select movie_id,
+					 case type
+						 when 'click' then 1.0
+						 when 'play' then 2.0
+						 when 'progress' then 2.0
+						 when 'complete' then 3.0
+					 end,
+					 occurred_at
+			  from events where occurred_at >= $1 and type <> 'impression'
+		  ) activity
+		  group by movie_id, hour`
+	r.logger.Debug("getting activity", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	activity := make([]trending.Activity, 0)
+	for rows.Next() {
+		var a trending.Activity
+		if err = rows.Scan(&a.MovieID, &a.Weight, &a.At); err != nil {
+			return nil, err
+		}
+		activity = append(activity, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+func (r *Repository) GetVotes(ctx context.Context) ([]trending.Votes, error) {
+	q := "select movie_id, count(*), avg(score)::float8 from ratings group by movie_id"
+	r.logger.Debug("getting votes", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	votes := make([]trending.Votes, 0)
+	for rows.Next() {
+		var v trending.Votes
+		if err = rows.Scan(&v.MovieID, &v.Count, &v.Mean); err != nil {
+			return nil, err
+		}
+		votes = append(votes, v)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return votes, nil
+}
@@ -0,0 +1,32 @@
+package trending
+
+import (
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"time"
+)
+
+// Windows supported by trending endpoint
+var Windows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// Activity is summed weight of ratings and events of movie in one hour
+type Activity struct {
+	MovieID string
+	Weight  float64
+	At      time.Time
+}
+
+// Votes are statistics of user ratings of movie
+type Votes struct {
+	MovieID string
+	Count   int
+	Mean    float64
+}
+
+type Ranked struct {
+	Movie movie.Movie `json:"movie"`
+	Score float64     `json:"score" example:"12.5"`
+}
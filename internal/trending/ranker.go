@@ -0,0 +1,198 @@
+package trending
+
+import (
+	"context"
+	"errors"
+	"github.com/danyatalent/movie-recommend/internal/exclusion"
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrUnknownWindow = errors.New("unknown window")
+
+type Source interface {
+	GetActivity(ctx context.Context, since time.Time) ([]Activity, error)
+	GetVotes(ctx context.Context) ([]Votes, error)
+}
+
+type MovieLookup interface {
+	Get(id string) (movie.Movie, bool)
+}
+
+// Ranker keeps trending and top lists computed in background, requests only filter and cut them
+type Ranker struct {
+	source     Source
+	movies     MovieLookup
+	exclusions exclusion.Source
+	logger     *slog.Logger
+	minVotes   float64
+
+	mu       sync.RWMutex
+	trending map[string][]Ranked
+	top      []Ranked
+}
+
+func NewRanker(source Source, movies MovieLookup, exclusions exclusion.Source, logger *slog.Logger, minVotes int) *Ranker {
+	return &Ranker{
+		source:     source,
+		movies:     movies,
+		exclusions: exclusions,
+		logger:     logger,
+		minVotes:   float64(minVotes),
+		trending:   make(map[string][]Ranked),
+	}
+}
+
+func (r *Ranker) Refresh(ctx context.Context) error {
+	now := time.Now()
+	var longest time.Duration
+	for _, window := range Windows {
+		longest = max(longest, window)
+	}
+	activity, err := r.source.GetActivity(ctx, now.Add(-longest))
+	if err != nil {
+		return err
+	}
+	trending := make(map[string][]Ranked, len(Windows))
+	for name, window := range Windows {
+		trending[name] = r.ranked(Decay(activity, now, window))
+	}
+
+	votes, err := r.source.GetVotes(ctx)
+	if err != nil {
+		return err
+	}
+	top := r.ranked(WeightedRatings(votes, r.minVotes))
+
+	r.mu.Lock()
+	r.trending = trending
+	r.top = top
+	r.mu.Unlock()
+	return nil
+}
+
+// Run refreshes rankings every interval until ctx is done
+func (r *Ranker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Refresh(ctx); err != nil {
+				r.logger.Error("failed to refresh rankings", logging.Err(err))
+			}
+		}
+	}
+}
+
+// Trending returns movies with the most decayed activity in window, genre is matched by id or name,
+// userID is optional and hides movies that user is not interested in
+func (r *Ranker) Trending(ctx context.Context, window, genre, userID string, limit int) ([]Ranked, error) {
+	if _, ok := Windows[window]; !ok {
+		return nil, ErrUnknownWindow
+	}
+	excluded, err := exclusion.Load(ctx, r.exclusions, userID)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	ranked := r.trending[window]
+	r.mu.RUnlock()
+	return filter(ranked, genre, excluded, limit), nil
+}
+
+// Top returns movies by bayesian weighted rating, genre is matched by id or name,
+// userID is optional and hides movies that user is not interested in
+func (r *Ranker) Top(ctx context.Context, genre, userID string, limit int) ([]Ranked, error) {
+	excluded, err := exclusion.Load(ctx, r.exclusions, userID)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	ranked := r.top
+	r.mu.RUnlock()
+	return filter(ranked, genre, excluded, limit), nil
+}
+
+func (r *Ranker) ranked(scores map[string]float64) []Ranked {
+	ranked := make([]Ranked, 0, len(scores))
+	for movieID, score := range scores {
+		m, ok := r.movies.Get(movieID)
+		if !ok {
+			continue
+		}
+		ranked = append(ranked, Ranked{Movie: m, Score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Movie.ID < ranked[j].Movie.ID
+	})
+	return ranked
+}
+
+func filter(ranked []Ranked, genre string, excluded exclusion.Set, limit int) []Ranked {
+	filtered := make([]Ranked, 0, limit)
+	for _, rm := range ranked {
+		if len(filtered) == limit {
+			break
+		}
+		if genre != "" && !hasGenre(rm.Movie, genre) || excluded.Excludes(rm.Movie) {
+			continue
+		}
+		filtered = append(filtered, rm)
+	}
+	return filtered
+}
+
+func hasGenre(m movie.Movie, genre string) bool {
+	for _, g := range m.Genres {
+		if g.ID == genre || strings.EqualFold(g.Name, genre) {
+			return true
+		}
+	}
+	return false
+}
+
+// Decay sums activity inside window with exponential decay, half-life is a quarter of window
+func Decay(activity []Activity, now time.Time, window time.Duration) map[string]float64 {
+	halfLife := float64(window) / 4
+	scores := make(map[string]float64)
+	for _, a := range activity {
+		age := now.Sub(a.At)
+		if age > window {
+			continue
+		}
+		scores[a.MovieID] += a.Weight * math.Exp(-math.Ln2*float64(max(age, 0))/halfLife)
+	}
+	return scores
+}
+
+// WeightedRatings is IMDb formula v/(v+m)*R + m/(v+m)*C, where C is mean score over all votes,
+// so a movie with few votes is pulled toward C and can't beat well established ones
+func WeightedRatings(votes []Votes, minVotes float64) map[string]float64 {
+	var sum, count float64
+	for _, v := range votes {
+		sum += v.Mean * float64(v.Count)
+		count += float64(v.Count)
+	}
+	scores := make(map[string]float64, len(votes))
+	if count == 0 {
+		return scores
+	}
+	c := sum / count
+	for _, v := range votes {
+		n := float64(v.Count)
+		scores[v.MovieID] = n/(n+minVotes)*v.Mean + minVotes/(n+minVotes)*c
+	}
+	return scores
+}
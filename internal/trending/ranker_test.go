@@ -0,0 +1,40 @@
+package trending
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWeightedRatings(t *testing.T) {
+	scores := WeightedRatings([]Votes{
+		{MovieID: "single", Count: 1, Mean: 10},
+		{MovieID: "classic", Count: 500, Mean: 8.5},
+		{MovieID: "flop", Count: 100, Mean: 3},
+	}, 25)
+	if scores["single"] >= scores["classic"] {
+		t.Errorf("single vote must not beat established title: %f >= %f", scores["single"], scores["classic"])
+	}
+	if scores["flop"] >= scores["single"] {
+		t.Errorf("flop must be the lowest: %v", scores)
+	}
+}
+
+func TestDecay(t *testing.T) {
+	now := time.Now()
+	window := 24 * time.Hour
+	scores := Decay([]Activity{
+		{MovieID: "fresh", Weight: 1, At: now},
+		{MovieID: "old", Weight: 1, At: now.Add(-6 * time.Hour)},
+		{MovieID: "expired", Weight: 100, At: now.Add(-25 * time.Hour)},
+	}, now, window)
+	if math.Abs(scores["fresh"]-1) > 1e-9 {
+		t.Errorf("fresh activity must not decay: %f", scores["fresh"])
+	}
+	if math.Abs(scores["old"]-0.5) > 1e-9 {
+		t.Errorf("activity of half-life age must be halved: %f", scores["old"])
+	}
+	if _, ok := scores["expired"]; ok {
+		t.Errorf("activity outside window must be ignored")
+	}
+}
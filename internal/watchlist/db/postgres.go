@@ -0,0 +1,158 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danyatalent/movie-recommend/internal/apperror"
+	"github.com/danyatalent/movie-recommend/internal/genre"
+	"github.com/danyatalent/movie-recommend/internal/rating"
+	"github.com/danyatalent/movie-recommend/internal/watchlist"
+	"github.com/danyatalent/movie-recommend/pkg/client/postgresql"
+	logging "github.com/danyatalent/movie-recommend/pkg/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"log/slog"
+)
+
+// sortColumns whitelists columns watchlist can be ordered by
+var sortColumns = map[string]string{
+	watchlist.SortAddedAt:  "w.added_at",
+	watchlist.SortRating:   "m.rating",
+	watchlist.SortDuration: "m.duration",
+}
+
+type Repository struct {
+	client postgresql.Client
+	logger *slog.Logger
+}
+
+func NewRepository(client postgresql.Client, logger *slog.Logger) *Repository {
+	return &Repository{
+		client: client,
+		logger: logger,
+	}
+}
+
+// AddToWatchlist is idempotent, adding saved movie again keeps original date
+func (r *Repository) AddToWatchlist(ctx context.Context, userID, movieID string) error {
+	q := "insert into watchlist(user_id, movie_id) values ($1, $2) on conflict do nothing"
+	r.logger.Info("adding to watchlist", slog.String("query", q))
+	if _, err := r.client.Exec(ctx, q, userID, movieID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.SQLState() == apperror.ErrForeignKeyCode {
+				return apperror.ErrEntityNotFound
+			}
+			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Code: %s, SQLState: %s",
+				pgErr.Message, pgErr.Detail, pgErr.Code, pgErr.SQLState()))
+			r.logger.Error("error due query", logging.Err(newErr))
+			return newErr
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) RemoveFromWatchlist(ctx context.Context, userID, movieID string) error {
+	q := "delete from watchlist where user_id=$1 and movie_id=$2"
+	r.logger.Info("removing from watchlist", slog.String("query", q))
+	result, err := r.client.Exec(ctx, q, userID, movieID)
+	if err != nil {
+		return fmt.Errorf("can't remove from watchlist: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return apperror.ErrEntityNotFound
+	}
+	return nil
+}
+
+func (r *Repository) GetWatchlist(ctx context.Context, userID string, page watchlist.Page) ([]watchlist.Item, error) {
+	column, ok := sortColumns[page.Sort]
+	if !ok {
+		column = sortColumns[watchlist.SortAddedAt]
+	}
+	order := "asc"
+	if page.Descending {
+		order = "desc"
+	}
+	q := fmt.Sprintf(`select m.id, m.name, coalesce(m.description, ''), coalesce(m.duration, 0), m.rating, m.director_id,
+				 coalesce(array_agg(g.id::text) filter (where g.id is not null), '{}'),
+				 coalesce(array_agg(g.name) filter (where g.id is not null), '{}'),
+				 w.added_at
+		  from watchlist w
+		  join movies m on m.id = w.movie_id
+		  left join movies_genres mg on mg.movie_id = m.id
+		  left join genres g on g.id = mg.genre_id
+		  where w.user_id = $1
+		  group by m.id, w.added_at
+		  order by %s %s, m.id
+		  limit $2 offset $3`, column, order)
+	r.logger.Debug("getting watchlist", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q, userID, page.Limit, (page.Number-1)*page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]watchlist.Item, 0)
+	for rows.Next() {
+		var (
+			item       watchlist.Item
+			genreIDs   []string
+			genreNames []string
+		)
+		m := &item.Movie
+		err = rows.Scan(&m.ID, &m.Name, &m.Description, &m.Duration, &m.Rating, &m.DirectorID,
+			&genreIDs, &genreNames, &item.AddedAt)
+		if err != nil {
+			return nil, err
+		}
+		m.Genres = make([]genre.Genre, 0, len(genreIDs))
+		for i := range genreIDs {
+			m.Genres = append(m.Genres, genre.Genre{ID: genreIDs[i], Name: genreNames[i]})
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetImplicitRatings treats every saved movie as positive signal for recommenders
+func (r *Repository) GetImplicitRatings(ctx context.Context) ([]rating.Rating, error) {
+	q := "select user_id, movie_id, added_at from watchlist"
+	r.logger.Debug("getting watchlist signals", slog.String("query", q))
+	rows, err := r.client.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return collectSignals(rows)
+}
+
+func (r *Repository) GetUserImplicitRatings(ctx context.Context, userID string) ([]rating.Rating, error) {
+	q := "select user_id, movie_id, added_at from watchlist where user_id=$1"
+	r.logger.Debug("getting watchlist signals of user", slog.String("user_id", userID))
+	rows, err := r.client.Query(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	return collectSignals(rows)
+}
+
+func collectSignals(rows pgx.Rows) ([]rating.Rating, error) {
+	defer rows.Close()
+	ratings := make([]rating.Rating, 0)
+	for rows.Next() {
+		rt := rating.Rating{Score: watchlist.ImplicitScore}
+		if err := rows.Scan(&rt.UserID, &rt.MovieID, &rt.RatedAt); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, rt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ratings, nil
+}
@@ -0,0 +1,28 @@
+package watchlist
+
+import (
+	"github.com/danyatalent/movie-recommend/internal/movie"
+	"time"
+)
+
+const (
+	SortAddedAt  = "added_at"
+	SortRating   = "rating"
+	SortDuration = "duration"
+)
+
+// ImplicitScore is preference on rating scale given to movie saved for later
+const ImplicitScore = 7
+
+type Item struct {
+	Movie   movie.Movie `json:"movie"`
+	AddedAt time.Time   `json:"added_at" example:"2024-03-17T12:00:00Z"`
+}
+
+// Page describes which part of watchlist is requested and in what order
+type Page struct {
+	Sort       string
+	Descending bool
+	Limit      int
+	Number     int
+}